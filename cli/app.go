@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/swarmlab-dev/go-tss/tssparty"
 	"github.com/urfave/cli"
 )
 
@@ -11,12 +12,18 @@ func main() {
 	app := cli.NewApp()
 	app.Name = "tss-cli"
 	app.Usage = "A simple command line to play with mpc-tss"
-	app.Version = "1.0.0"
+	app.Version = tssparty.Version
 
 	app.Commands = []cli.Command{
 		keygenCmd(),
 		signingCmd(),
 		resharingCmd(),
+		identityCmd(),
+		keystoreCmd(),
+		verifyCmd(),
+		pubkeyCmd(),
+		preparamsCmd(),
+		daemonCmd(),
 	}
 
 	err := app.Run(os.Args)
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/swarmlab-dev/go-tss/daemon"
+	"github.com/swarmlab-dev/go-tss/tssparty"
+	"github.com/urfave/cli"
+)
+
+func daemonCmd() cli.Command {
+	return cli.Command{
+		Name:  "daemon",
+		Usage: "Hold the key shares of the keystore and run the ceremonies requested through a local HTTP API",
+		Flags: append([]cli.Flag{
+			cli.StringFlag{
+				Name:  "bus",
+				Value: "127.0.0.1:8080",
+				Usage: "party bus URL",
+			},
+			cli.StringFlag{
+				Name:  "listen",
+				Value: "127.0.0.1:8642",
+				Usage: "address of the HTTP API, keep it local unless --api-token-file is set",
+			},
+			cli.StringFlag{
+				Name:  "api-token-file",
+				Usage: "file holding the bearer token required by the HTTP API, or lines of a requester name and its token",
+			},
+			cli.StringFlag{
+				Name:  "policy-file",
+				Usage: "json rules deciding which signing requests are signed",
+			},
+			preparamsTimeoutFlag(),
+		}, append(identityFlags(), keystoreFlags()...)...),
+		Action: func(c *cli.Context) error {
+			config, err := daemonConfig(c)
+			if err != nil {
+				return err
+			}
+			d, err := daemon.New(*config)
+			if err != nil {
+				return err
+			}
+			defer d.Close()
+
+			server := &http.Server{
+				Addr:              c.String("listen"),
+				Handler:           d.Handler(),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				server.Shutdown(shutdownCtx)
+			}()
+
+			fmt.Fprintf(os.Stderr, "listening on %s\n", server.Addr)
+			err = server.ListenAndServe()
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return err
+		},
+	}
+}
+
+func daemonConfig(c *cli.Context) (*daemon.Config, error) {
+	ks, err := openKeystore(c)
+	if err != nil {
+		return nil, err
+	}
+	password, err := keystorePassword(c)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := ks.Pool(preParamsPool)
+	if err != nil {
+		return nil, err
+	}
+
+	partyBusUrl := c.String("bus")
+	config := &daemon.Config{
+		Keystore:      ks,
+		Password:      password,
+		NewTransport:  func() tssparty.Transport { return tssparty.NewPartyBusTransport(partyBusUrl) },
+		PreParamsPool: pool,
+		Insecure:      c.Bool("insecure"),
+		Timeouts:      &tssparty.Timeouts{PreParams: c.Duration("preparams-timeout")},
+	}
+
+	if path := c.String("identity"); path != "" {
+		config.IdentityKey, err = tssparty.LoadIdentityKey(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if path := c.String("roster"); path != "" {
+		config.Roster, err = tssparty.LoadRoster(path)
+		if err != nil {
+			return nil, err
+		}
+	} else if !config.Insecure {
+		return nil, fmt.Errorf("a roster (roster) is required to authenticate the peers, or --insecure to run without")
+	}
+	if path := c.String("api-token-file"); path != "" {
+		config.ApiTokens, err = loadApiTokens(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if path := c.String("policy-file"); path != "" {
+		config.Policy, err = daemon.LoadRules(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// loadApiTokens reads one token per line, preceded by the name of its requester and a space,
+// a file holding a single token names its requester default
+func loadApiTokens(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if fields := strings.Fields(string(data)); len(fields) <= 1 {
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("api token file %s is empty", path)
+		}
+		return map[string]string{fields[0]: "default"}, nil
+	}
+
+	tokens := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("api token file %s must hold a token or lines of a requester name and a token", path)
+		}
+		tokens[fields[1]] = fields[0]
+	}
+	return tokens, nil
+}
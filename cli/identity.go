@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/anandvarma/namegen"
+	"github.com/swarmlab-dev/go-tss/tssparty"
+	"github.com/urfave/cli"
+)
+
+func identityCmd() cli.Command {
+	return cli.Command{
+		Name:  "identity",
+		Usage: "Generate a long-term identity key and print its roster entry",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "p",
+				Usage: "this peer id",
+				Value: namegen.New().Get(),
+			},
+			cli.StringFlag{
+				Name:  "o",
+				Usage: "file to write the identity key to",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			output := c.String("o")
+			if output == "" {
+				return fmt.Errorf("an output file (o) is required")
+			}
+
+			key, err := tssparty.NewIdentityKey()
+			if err != nil {
+				return err
+			}
+			err = writeNewFile(output, []byte(hex.EncodeToString(key.Seed())))
+			if err != nil {
+				return err
+			}
+
+			entry, err := json.Marshal(tssparty.RosterEntry{Id: c.String("p"), IdentityKey: key.Public().(ed25519.PublicKey)})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s\n", entry)
+			return nil
+		},
+	}
+}
+
+func identityFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "identity",
+			Usage: "file holding this peer's identity key",
+		},
+		cli.StringFlag{
+			Name:  "roster",
+			Usage: "json file listing the expected peers and their identity keys",
+		},
+		cli.BoolFlag{
+			Name:  "insecure",
+			Usage: "run without roster, the operator of the bus can then impersonate the peers and read the private shares sent to them",
+		},
+	}
+}
+
+// identityHolder is a party, or the coordinator of a lobby
+type identityHolder interface {
+	SetIdentity(identityKey ed25519.PrivateKey) error
+	SetRoster(roster []tssparty.RosterEntry) error
+	SetInsecure(insecure bool)
+}
+
+func setupIdentity(c *cli.Context, party identityHolder) error {
+	if c.String("roster") == "" {
+		if !c.Bool("insecure") {
+			return fmt.Errorf("a roster (roster) is required to authenticate the peers, or --insecure to run without")
+		}
+		warnUnauthenticated.Do(func() {
+			fmt.Fprintf(os.Stderr, "WARNING: without --roster the peers are not authenticated, the operator of the bus can impersonate them and read the private shares sent to them\n")
+		})
+		party.SetInsecure(true)
+	}
+
+	if path := c.String("identity"); path != "" {
+		key, err := tssparty.LoadIdentityKey(path)
+		if err != nil {
+			return err
+		}
+		err = party.SetIdentity(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	if path := c.String("roster"); path != "" {
+		roster, err := tssparty.LoadRoster(path)
+		if err != nil {
+			return err
+		}
+		err = party.SetRoster(roster)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// warnUnauthenticated warns once per run, a lobby sets up the identity of the coordinator and of the party
+var warnUnauthenticated sync.Once
+
+// writeNewFile writes a secret to a file readable by the owner only, an existing file is never overwritten
+func writeNewFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists, remove it first to replace it", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
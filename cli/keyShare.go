@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/swarmlab-dev/go-tss/tssparty"
+	"github.com/urfave/cli"
+)
+
+// readKeyShare returns the key share stored under the name given with --key,
+// or the one given with -k, read from stdin when set to -
+func readKeyShare(c *cli.Context) (string, error) {
+	if name := c.String("key"); name != "" {
+		return loadKeyShare(c, name)
+	}
+
+	keyShare := c.String("k")
+	if keyShare == "-" {
+		keyShareB, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		keyShare = string(keyShareB)
+	}
+	return keyShare, nil
+}
+
+// isEddsaKeyShare tells whether --eddsa is set or the key share is an ed25519 one
+func isEddsaKeyShare(c *cli.Context, keyShare string) (bool, error) {
+	if c.Bool("eddsa") {
+		return true, nil
+	}
+	share, err := tssparty.ParseKeyShare(keyShare)
+	if err != nil {
+		return false, err
+	}
+	return share.Curve == tssparty.CurveEd25519, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/anandvarma/namegen"
@@ -13,7 +14,7 @@ func keygenCmd() cli.Command {
 		Name:    "keygen",
 		Aliases: []string{"k"},
 		Usage:   "Keygen threshold ceremony to create a new party",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			cli.StringFlag{
 				Name:  "bus",
 				Value: "127.0.0.1:8080",
@@ -43,7 +44,13 @@ func keygenCmd() cli.Command {
 				Value: 2,
 				Usage: "number of party necessary to sign (threshold)",
 			},
-		},
+			cli.StringFlag{
+				Name:  "save",
+				Usage: "store the key share in the keystore under this name instead of printing it",
+			},
+			preparamsFlag(),
+			preparamsTimeoutFlag(),
+		}, append(append(identityFlags(), keystoreFlags()...), lobbyFlags()...)...),
 		Action: func(c *cli.Context) error {
 			partyBusUrl := c.String("bus")
 			sessionId := c.String("s")
@@ -51,18 +58,59 @@ func keygenCmd() cli.Command {
 			partycount := c.Int("n")
 			threshold := c.Int("t")
 
+			if c.Bool("eddsa") && c.Bool("preparams") {
+				return fmt.Errorf("preparams are only used by ecdsa")
+			}
+			err := checkSaveKeyShare(c)
+			if err != nil {
+				return err
+			}
+
+			curve := tssparty.CurveSecp256k1
+			if c.Bool("eddsa") {
+				curve = tssparty.CurveEd25519
+			}
+			local := tssparty.Proposal{Protocol: tssparty.ProtocolKeygen, Curve: curve, N: partycount, T: threshold}
+			proposal, err := coordinate(c, partyId, local, func(proposal tssparty.Proposal) error {
+				return errors.Join(checkProposedCurve(proposal, c.Bool("eddsa")), checkProposedCount(c, "n", proposal.N), checkProposedCount(c, "t", proposal.T))
+			})
+			if err != nil {
+				return err
+			}
+			if proposal != nil {
+				sessionId, partycount, threshold = proposal.Id, proposal.N, proposal.T
+			}
+
 			var tssParty tssparty.KeygenTssParty
 
 			if c.Bool("eddsa") {
 				tssParty = tssparty.NewEddsaKeygenTssParty(partyId, partycount, threshold)
+			} else if c.Bool("preparams") {
+				preParams, err := takePreParams(c)
+				if err != nil {
+					return err
+				}
+				tssParty, err = tssparty.NewEcdsaKeygenTssPartyWithPreParams(partyId, preParams, partycount, threshold)
+				if err != nil {
+					return err
+				}
 			} else {
 				tssParty = tssparty.NewEcdsaKeygenTssParty(partyId, partycount, threshold)
 			}
 
+			tssParty.SetTimeouts(tssparty.Timeouts{PreParams: c.Duration("preparams-timeout")})
+			err = setupIdentity(c, tssParty)
+			if err != nil {
+				return err
+			}
+
 			keyShare, err := tssparty.ConnectAndGetKeyShare(tssParty, partyBusUrl, sessionId)
 			if err != nil {
 				return err
 			}
+			if c.String("save") != "" {
+				return saveKeyShare(c, keyShare)
+			}
 			fmt.Printf("%s\n", keyShare)
 			return nil
 		},
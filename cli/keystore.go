@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/swarmlab-dev/go-tss/keystore"
+	"github.com/swarmlab-dev/go-tss/tssparty"
+	"github.com/urfave/cli"
+)
+
+const passwordEnvVar = "TSSPARTY_KEYSTORE_PASSWORD"
+
+func keystoreCmd() cli.Command {
+	return cli.Command{
+		Name:  "keystore",
+		Usage: "Manage the key shares of the encrypted keystore",
+		Subcommands: []cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the key shares of the keystore",
+				Flags: keystoreFlags(),
+				Action: func(c *cli.Context) error {
+					ks, err := openKeystore(c)
+					if err != nil {
+						return err
+					}
+					names, err := ks.List()
+					if err != nil {
+						return err
+					}
+					for _, name := range names {
+						fmt.Printf("%s\n", name)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "import",
+				Usage: "Import a key share into the keystore",
+				Flags: append([]cli.Flag{
+					cli.StringFlag{
+						Name:  "k",
+						Usage: "key share to import (- to read it from stdin)",
+						Value: "-",
+					},
+					cli.StringFlag{
+						Name:  "save",
+						Usage: "name of the key share in the keystore",
+					},
+				}, keystoreFlags()...),
+				Action: func(c *cli.Context) error {
+					if c.String("save") == "" {
+						return fmt.Errorf("a key name is required (--save)")
+					}
+					keyShare, err := readKeyShare(c)
+					if err != nil {
+						return err
+					}
+					_, err = tssparty.ParseKeyShare(keyShare)
+					if err != nil {
+						return err
+					}
+					return saveKeyShare(c, keyShare)
+				},
+			},
+		},
+	}
+}
+
+func keystoreFlags() []cli.Flag {
+	dir := "keystore"
+	if home, err := os.UserHomeDir(); err == nil {
+		dir = filepath.Join(home, ".tssparty", "keystore")
+	}
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "keystore",
+			Value: dir,
+			Usage: "directory of the encrypted keystore",
+		},
+		cli.StringFlag{
+			Name:  "password-file",
+			Usage: "file holding the keystore password (default is the " + passwordEnvVar + " environment variable)",
+		},
+	}
+}
+
+func openKeystore(c *cli.Context) (*keystore.Keystore, error) {
+	return keystore.Open(c.String("keystore"))
+}
+
+func keystorePassword(c *cli.Context) ([]byte, error) {
+	if path := c.String("password-file"); path != "" {
+		password, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.TrimRight(string(password), "\r\n")), nil
+	}
+	if password, ok := os.LookupEnv(passwordEnvVar); ok {
+		return []byte(password), nil
+	}
+	return nil, fmt.Errorf("no keystore password, use --password-file or %s", passwordEnvVar)
+}
+
+// loadKeyShare reads the key share stored under name
+func loadKeyShare(c *cli.Context, name string) (string, error) {
+	ks, err := openKeystore(c)
+	if err != nil {
+		return "", err
+	}
+	password, err := keystorePassword(c)
+	if err != nil {
+		return "", err
+	}
+	keyShare, err := ks.Get(name, password)
+	if err != nil {
+		return "", err
+	}
+	return string(keyShare), nil
+}
+
+// checkSaveKeyShare makes sure the share can be saved under the name given with --save
+// before running a ceremony
+func checkSaveKeyShare(c *cli.Context) error {
+	name := c.String("save")
+	if name == "" {
+		return nil
+	}
+	ks, err := openKeystore(c)
+	if err != nil {
+		return err
+	}
+	_, err = keystorePassword(c)
+	if err != nil {
+		return err
+	}
+	exists, err := ks.Has(name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", keystore.ErrExists, name)
+	}
+	return nil
+}
+
+// saveKeyShare stores the key share under the name given with --save and prints its public key
+func saveKeyShare(c *cli.Context, keyShare string) error {
+	ks, err := openKeystore(c)
+	if err != nil {
+		return err
+	}
+	password, err := keystorePassword(c)
+	if err != nil {
+		return err
+	}
+	err = ks.Put(c.String("save"), []byte(keyShare), password)
+	if err != nil {
+		return err
+	}
+
+	share, err := tssparty.ParseKeyShare(keyShare)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", share.PublicKey)
+	return nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/swarmlab-dev/go-tss/tssparty"
+	"github.com/urfave/cli"
+)
+
+func lobbyFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "lobby",
+			Usage: "meet the participants in this session of the bus, the ceremony then runs in the session of the accepted proposal (instead of -s)",
+		},
+		cli.BoolFlag{
+			Name:  "propose",
+			Usage: "propose the ceremony to the participants of the lobby, the other participants wait for it",
+		},
+		cli.StringFlag{
+			Name:  "participants",
+			Usage: "comma separated ids of the participants of the proposed ceremony",
+		},
+		cli.IntFlag{
+			Name:  "quorum",
+			Usage: "acceptances required to start a proposed signing (default is t+1), a keygen or a resharing needs every participant",
+		},
+		cli.BoolFlag{
+			Name:  "yes",
+			Usage: "accept the proposal, or sign, without asking",
+		},
+	}
+}
+
+// coordinate meets the participants in the lobby given with --lobby, it returns nil without lobby.
+// With --propose the local ceremony is proposed, otherwise the local party waits for a proposal of
+// the same protocol that passes check, and asks the operator to accept it.
+func coordinate(c *cli.Context, partyId string, local tssparty.Proposal, check func(proposal tssparty.Proposal) error) (*tssparty.Proposal, error) {
+	lobby := c.String("lobby")
+	if lobby == "" {
+		if c.Bool("propose") {
+			return nil, fmt.Errorf("--propose requires --lobby")
+		}
+		return nil, nil
+	}
+	if partyId == "" {
+		return nil, fmt.Errorf("a peer id (-p) is required to join a lobby")
+	}
+	if !c.Bool("propose") {
+		err := checkOperator(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	coordinator := tssparty.NewCoordinator(partyId)
+	err := setupIdentity(c, coordinator)
+	if err != nil {
+		return nil, err
+	}
+	err = coordinator.Connect(tssparty.NewPartyBusTransport(c.String("bus")), lobby)
+	if err != nil {
+		return nil, err
+	}
+	defer coordinator.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if c.Bool("propose") {
+		if c.String("participants") == "" {
+			return nil, fmt.Errorf("--participants is required to propose a ceremony")
+		}
+		local.Participants = strings.Split(c.String("participants"), ",")
+		local.Quorum = c.Int("quorum")
+		fmt.Fprintf(os.Stderr, "waiting for the participants to accept the proposal...\n")
+		return coordinator.ProposeContext(ctx, local)
+	}
+
+	fmt.Fprintf(os.Stderr, "waiting for a %s proposal in lobby %s...\n", local.Protocol, lobby)
+	return coordinator.AttendContext(ctx, func(ctx context.Context, proposal tssparty.Proposal) error {
+		if proposal.Protocol != local.Protocol {
+			return fmt.Errorf("peer %s waits for a %s proposal", partyId, local.Protocol)
+		}
+		err := check(proposal)
+		if err != nil {
+			return err
+		}
+		return confirmProposal(ctx, c, proposal)
+	})
+}
+
+// confirmProposal shows a proposal to the operator and asks whether to accept it, unless --yes is set.
+// The question is given up when ctx is done, along with the proposal.
+func confirmProposal(ctx context.Context, c *cli.Context, proposal tssparty.Proposal) error {
+	fmt.Fprintf(os.Stderr, "\n%s proposes a %s ceremony on %s\n", proposal.Proposer, proposal.Protocol, proposal.Curve)
+	fmt.Fprintf(os.Stderr, "  n=%v t=%v", proposal.N, proposal.T)
+	if proposal.Protocol == tssparty.ProtocolResharing {
+		fmt.Fprintf(os.Stderr, " new-n=%v new-t=%v", proposal.NewN, proposal.NewT)
+	}
+	fmt.Fprintf(os.Stderr, "\n  participants: %s, %v must accept\n", strings.Join(proposal.Participants, ", "), proposal.Quorum)
+	if proposal.PublicKey != "" {
+		fmt.Fprintf(os.Stderr, "  public key: %s\n", proposal.PublicKey)
+	}
+	if proposal.Protocol == tssparty.ProtocolSigning {
+		if proposal.Path != "" {
+			fmt.Fprintf(os.Stderr, "  path: %s\n", proposal.Path)
+		}
+		printMessages(proposal.HashMode, proposal.Messages, proposal.Digests)
+	}
+
+	if c.Bool("yes") {
+		return nil
+	}
+	return askOperator(ctx, "accept?")
+}
+
+// printMessages shows messages to sign along with the values signed
+func printMessages(hashMode tssparty.HashMode, msgs [][]byte, digests [][]byte) {
+	fmt.Fprintf(os.Stderr, "  hash: %s\n", hashMode)
+	for i, msg := range msgs {
+		if hashMode == tssparty.HashModeDigestHex || hashMode == tssparty.HashModeDigestBase64 {
+			fmt.Fprintf(os.Stderr, "  digest %s\n", hex.EncodeToString(digests[i]))
+			continue
+		}
+		// the keccak256 hash of an unsigned transaction is what ethereum wallets sign
+		if tx, err := tssparty.ParseEthereumTransaction(msg); err == nil && hashMode == tssparty.HashModeKeccak256 {
+			printTransaction(tx, len(msg))
+		} else {
+			fmt.Fprintf(os.Stderr, "  message %s\n", describeMessage(msg))
+		}
+		if hashMode != tssparty.HashModeEddsa {
+			fmt.Fprintf(os.Stderr, "    digest %s\n", hex.EncodeToString(digests[i]))
+		}
+	}
+}
+
+// describeMessage quotes a text message, and shows other messages in hex
+func describeMessage(msg []byte) string {
+	if utf8.Valid(msg) && !bytes.ContainsFunc(msg, func(r rune) bool {
+		return !unicode.IsPrint(r) && !unicode.IsSpace(r)
+	}) {
+		return strconv.Quote(string(msg))
+	}
+	return fmt.Sprintf("0x%s (%v bytes)", hex.EncodeToString(msg), len(msg))
+}
+
+// operatorInput is the terminal the operator answers on
+var operatorInput = os.Stdin
+
+// checkOperator makes sure that the operator can be asked on the terminal, unless --yes is set.
+// It is checked before joining a session so that the peers do not wait for an answer that cannot come.
+func checkOperator(c *cli.Context) error {
+	if c.Bool("yes") {
+		return nil
+	}
+	if c.String("k") == "-" {
+		return fmt.Errorf("the key share is read from the standard input, the operator cannot be asked: use --yes to accept without asking")
+	}
+	if !isTerminal(operatorInput) {
+		return fmt.Errorf("the standard input is not a terminal, the operator cannot be asked: use --yes to accept without asking")
+	}
+	return nil
+}
+
+// askOperator asks a yes or no question on the terminal, anything but yes is an error
+func askOperator(ctx context.Context, question string) error {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answers := make(chan string, 1)
+	input := operatorInput
+	go func() {
+		answer, err := bufio.NewReader(input).ReadString('\n')
+		if err != nil {
+			close(answers)
+			return
+		}
+		answers <- answer
+	}()
+
+	select {
+	case answer, ok := <-answers:
+		if !ok {
+			return fmt.Errorf("cannot ask the operator, use --yes to accept without asking")
+		}
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			return fmt.Errorf("rejected by the operator")
+		}
+		return nil
+	case <-ctx.Done():
+		fmt.Fprintf(os.Stderr, "\n")
+		return ctx.Err()
+	}
+}
+
+// checkProposedCount makes sure a count given on the command line matches the proposed one
+func checkProposedCount(c *cli.Context, flag string, proposed int) error {
+	if c.IsSet(flag) && c.Int(flag) != proposed {
+		return fmt.Errorf("proposed %s=%v but %v is given", flag, proposed, c.Int(flag))
+	}
+	return nil
+}
+
+// checkProposedCurve makes sure the local party uses the proposed curve
+func checkProposedCurve(proposal tssparty.Proposal, eddsa bool) error {
+	curve := tssparty.CurveSecp256k1
+	if eddsa {
+		curve = tssparty.CurveEd25519
+	}
+	if proposal.Curve != curve {
+		return fmt.Errorf("proposed curve %s but the local party uses %s", proposal.Curve, curve)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/swarmlab-dev/go-tss/tssparty"
+	"github.com/urfave/cli"
+)
+
+// testContext parses the flags the operator checks read
+func testContext(t *testing.T, args ...string) *cli.Context {
+	t.Helper()
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.Bool("yes", false, "")
+	set.String("k", "", "")
+	err := set.Parse(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cli.NewContext(nil, set, nil)
+}
+
+// answerOperator replaces the terminal of the operator with a pipe, the returned file writes the answers.
+// It is closed when the test ends.
+func answerOperator(t *testing.T) *os.File {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := operatorInput
+	operatorInput = reader
+	t.Cleanup(func() {
+		operatorInput = input
+		writer.Close()
+		reader.Close()
+	})
+	return writer
+}
+
+func TestCheckOperator(t *testing.T) {
+	answerOperator(t)
+	tests := []struct {
+		args    []string
+		invalid string
+	}{
+		{[]string{"--yes"}, ""},
+		{[]string{"--yes", "-k", "-"}, ""},
+		{[]string{"-k", "-"}, "the key share is read from the standard input"},
+		{nil, "the standard input is not a terminal"},
+	}
+	for _, test := range tests {
+		err := checkOperator(testContext(t, test.args...))
+		if test.invalid == "" && err != nil {
+			t.Errorf("%v: %s", test.args, err)
+		}
+		if test.invalid != "" && (err == nil || !strings.Contains(err.Error(), test.invalid) || !strings.Contains(err.Error(), "--yes")) {
+			t.Errorf("%v: expected %q, got: %v", test.args, test.invalid, err)
+		}
+	}
+}
+
+func TestAskOperator(t *testing.T) {
+	tests := []struct {
+		answer   string
+		accepted bool
+	}{
+		{"y\n", true},
+		{"yes\n", true},
+		{" YES \n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"yes please\n", false},
+	}
+	for _, test := range tests {
+		answers := answerOperator(t)
+		_, err := answers.WriteString(test.answer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = askOperator(context.Background(), "sign?")
+		if test.accepted && err != nil {
+			t.Errorf("answer %q: %s", test.answer, err)
+		}
+		if !test.accepted && (err == nil || err.Error() != "rejected by the operator") {
+			t.Errorf("answer %q: expected a rejection, got: %v", test.answer, err)
+		}
+	}
+
+	// an input closed before the answer cannot tell yes
+	answers := answerOperator(t)
+	answers.WriteString("y")
+	answers.Close()
+	err := askOperator(context.Background(), "sign?")
+	if err == nil || !strings.Contains(err.Error(), "cannot ask the operator") {
+		t.Errorf("expected the question to fail, got: %v", err)
+	}
+}
+
+func TestConfirmProposalGivesUp(t *testing.T) {
+	// the operator never answers, the proposal is given up with the lobby
+	answerOperator(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	proposal := tssparty.Proposal{Protocol: tssparty.ProtocolKeygen, Curve: tssparty.CurveEd25519, N: 3, T: 1, Participants: []string{"p0", "p1", "p2"}}
+	err := confirmProposal(ctx, testContext(t), proposal)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the question to be given up, got: %v", err)
+	}
+	if err := confirmProposal(ctx, testContext(t, "--yes"), proposal); err != nil {
+		t.Fatalf("--yes asked the operator: %s", err)
+	}
+}
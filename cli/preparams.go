@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/swarmlab-dev/go-tss/keystore"
+	"github.com/swarmlab-dev/go-tss/tssparty"
+	"github.com/urfave/cli"
+)
+
+const preParamsPool = "preparams"
+
+func preparamsCmd() cli.Command {
+	return cli.Command{
+		Name:  "preparams",
+		Usage: "Generate ecdsa preparams ahead of time into the keystore pool used by keygen and resharing",
+		Flags: append([]cli.Flag{
+			cli.IntFlag{
+				Name:  "c",
+				Value: 1,
+				Usage: "number of preparams to generate",
+			},
+			cli.IntFlag{
+				Name:  "concurrency",
+				Value: runtime.NumCPU(),
+				Usage: "number of cores used to generate each preparams",
+			},
+			cli.DurationFlag{
+				Name:  "timeout",
+				Value: 10 * time.Minute,
+				Usage: "give up generating one preparams after this duration",
+			},
+		}, keystoreFlags()...),
+		Action: func(c *cli.Context) error {
+			count := c.Int("c")
+			ks, err := openKeystore(c)
+			if err != nil {
+				return err
+			}
+			pool, err := ks.Pool(preParamsPool)
+			if err != nil {
+				return err
+			}
+			password, err := keystorePassword(c)
+			if err != nil {
+				return err
+			}
+
+			for i := 1; i <= count; i++ {
+				fmt.Fprintf(os.Stderr, "generating preparams %v/%v...\n", i, count)
+				start := time.Now()
+				ctx, cancel := context.WithTimeout(context.Background(), c.Duration("timeout"))
+				stop := reportProgress(i, count, start)
+				preParams, err := tssparty.GeneratePreParams(ctx, c.Int("concurrency"))
+				stop()
+				cancel()
+				if err != nil {
+					return err
+				}
+				name, err := pool.Add([]byte(preParams), password)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "generated preparams %v/%v in %s: %s\n", i, count, time.Since(start).Round(time.Second/10), name)
+			}
+
+			names, err := pool.Names()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%v preparams in the pool\n", len(names))
+			return nil
+		},
+	}
+}
+
+func preparamsFlag() cli.Flag {
+	return cli.BoolFlag{
+		Name:  "preparams",
+		Usage: "take the ecdsa preparams from the keystore pool filled by the preparams command instead of computing them",
+	}
+}
+
+func preparamsTimeoutFlag() cli.Flag {
+	return cli.DurationFlag{
+		Name:  "preparams-timeout",
+		Value: 10 * time.Minute,
+		Usage: "give up computing the ecdsa preparams after this duration, when they are not taken from the pool",
+	}
+}
+
+// preparamsProgress is how often the preparams command tells that a generation is still running
+const preparamsProgress = 10 * time.Second
+
+// reportProgress prints the time spent generating the i-th preparams until stop is called
+func reportProgress(i int, count int, start time.Time) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(preparamsProgress)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fmt.Fprintf(os.Stderr, "still generating preparams %v/%v after %s...\n", i, count, time.Since(start).Round(time.Second))
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// takePreParams removes one preparams from the keystore pool, they are never used twice
+func takePreParams(c *cli.Context) (string, error) {
+	ks, err := openKeystore(c)
+	if err != nil {
+		return "", err
+	}
+	pool, err := ks.Pool(preParamsPool)
+	if err != nil {
+		return "", err
+	}
+	password, err := keystorePassword(c)
+	if err != nil {
+		return "", err
+	}
+	preParams, err := pool.Take(password)
+	if errors.Is(err, keystore.ErrPoolEmpty) {
+		return "", fmt.Errorf("%w, generate some with the preparams command", err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(preParams), nil
+}
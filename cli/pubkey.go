@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/swarmlab-dev/go-tss/tssparty"
+	"github.com/urfave/cli"
+)
+
+func pubkeyCmd() cli.Command {
+	return cli.Command{
+		Name:  "pubkey",
+		Usage: "Print the group public key of a key share and its chain addresses",
+		Flags: append([]cli.Flag{
+			cli.StringFlag{
+				Name:  "k",
+				Usage: "key share (- to read it from stdin)",
+			},
+			cli.StringFlag{
+				Name:  "key",
+				Usage: "name of the key share in the keystore (instead of -k)",
+			},
+			cli.StringFlag{
+				Name:  "format",
+				Usage: "only print this form: sec1, sec1-uncompressed, hex, base58, jwk, pem or xpub",
+			},
+			cli.StringFlag{
+				Name:  "path",
+				Usage: "BIP32 non-hardened derivation path of the child key to print, such as m/0/1 (ecdsa key shares with a chain code)",
+			},
+			cli.StringFlag{
+				Name:  "address",
+				Usage: "only print this address: eth, btc-p2pkh, btc-p2wpkh, btc-p2tr, cosmos or solana",
+			},
+			cli.StringFlag{
+				Name:  "hrp",
+				Value: "cosmos",
+				Usage: "human readable part of the cosmos address",
+			},
+		}, keystoreFlags()...),
+		Action: func(c *cli.Context) error {
+			keyShare, err := readKeyShare(c)
+			if err != nil {
+				return err
+			}
+			if keyShare == "" {
+				return fmt.Errorf("a key share is required")
+			}
+			pub, xpub, err := keySharePublicKey(keyShare, c.String("path"))
+			if err != nil {
+				return err
+			}
+
+			if c.String("format") == "xpub" {
+				if xpub == nil {
+					return fmt.Errorf("key share has no chain code, it has no xpub")
+				}
+				fmt.Printf("%s\n", xpub)
+				return nil
+			}
+			if format := c.String("format"); format != "" {
+				encoded, err := publicKeyForm(pub, format)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s\n", strings.TrimSpace(encoded))
+				return nil
+			}
+			if chain := c.String("address"); chain != "" {
+				address, err := publicKeyAddress(pub, chain, c.String("hrp"))
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s\n", address)
+				return nil
+			}
+
+			// print every form and address available for the curve
+			fmt.Printf("curve: %s\n", pub.Curve)
+			if path := c.String("path"); path != "" {
+				fmt.Printf("path: %s\n", path)
+			}
+			for _, format := range []string{"sec1", "sec1-uncompressed", "hex", "base58", "jwk", "pem"} {
+				if encoded, err := publicKeyForm(pub, format); err == nil {
+					fmt.Printf("%s: %s\n", format, strings.TrimSpace(encoded))
+				}
+			}
+			if xpub != nil {
+				fmt.Printf("xpub: %s\n", xpub)
+			}
+			for _, chain := range []string{"eth", "btc-p2pkh", "btc-p2wpkh", "btc-p2tr", "cosmos", "solana"} {
+				if address, err := publicKeyAddress(pub, chain, c.String("hrp")); err == nil {
+					fmt.Printf("%s: %s\n", chain, address)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// keySharePublicKey returns the public key of a key share, or of its child at path, along with
+// the extended public key when the key share has a chain code
+func keySharePublicKey(keyShare string, path string) (*tssparty.PublicKey, *tssparty.ExtendedPublicKey, error) {
+	xpub, err := tssparty.KeyShareExtendedPublicKey(keyShare)
+	if err != nil && path == "" {
+		pub, err := tssparty.KeySharePublicKey(keyShare)
+		return pub, nil, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	xpub, err = xpub.Derive(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub, err := xpub.PublicKey()
+	return pub, xpub, err
+}
+
+func publicKeyForm(pub *tssparty.PublicKey, format string) (string, error) {
+	switch format {
+	case "sec1":
+		if pub.Curve != tssparty.CurveSecp256k1 {
+			return "", fmt.Errorf("sec1 is only available for %s", tssparty.CurveSecp256k1)
+		}
+		return pub.Hex(), nil
+	case "sec1-uncompressed":
+		uncompressed, err := pub.Uncompressed()
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(uncompressed), nil
+	case "hex":
+		return pub.Hex(), nil
+	case "base58":
+		return pub.Base58(), nil
+	case "jwk":
+		jwk, err := pub.JWK()
+		return string(jwk), err
+	case "pem":
+		pem, err := pub.PEM()
+		return "\n" + string(pem), err
+	}
+	return "", fmt.Errorf("unknown public key format %s", format)
+}
+
+func publicKeyAddress(pub *tssparty.PublicKey, chain string, hrp string) (string, error) {
+	switch chain {
+	case "eth":
+		return pub.EthereumAddress()
+	case "btc-p2pkh":
+		return pub.BitcoinP2PKHAddress()
+	case "btc-p2wpkh":
+		return pub.BitcoinP2WPKHAddress()
+	case "btc-p2tr":
+		return pub.BitcoinP2TRAddress()
+	case "cosmos":
+		return pub.CosmosAddress(hrp)
+	case "solana":
+		return pub.SolanaAddress()
+	}
+	return "", fmt.Errorf("unknown address type %s", chain)
+}
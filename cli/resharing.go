@@ -1,6 +1,12 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anandvarma/namegen"
+	"github.com/swarmlab-dev/go-tss/tssparty"
 	"github.com/urfave/cli"
 )
 
@@ -9,8 +15,188 @@ func resharingCmd() cli.Command {
 		Name:    "resharing",
 		Aliases: []string{"r"},
 		Usage:   "Resharing threshold ceremony to create fresh shares",
+		Flags: append([]cli.Flag{
+			cli.StringFlag{
+				Name:  "bus",
+				Value: "127.0.0.1:8080",
+				Usage: "party bus URL",
+			},
+			cli.StringFlag{
+				Name:  "s",
+				Value: namegen.New().Get(),
+				Usage: "resharing party session id",
+			},
+			cli.StringFlag{
+				Name:  "p",
+				Usage: "this peer id (defaults to the key share's)",
+			},
+			cli.StringFlag{
+				Name:  "k",
+				Usage: "this peer's old key share (leave empty to join the new committee)",
+			},
+			cli.BoolFlag{
+				Name:  "eddsa",
+				Usage: "set resharing for eddsa (default is the key share's curve, or ecdsa)",
+			},
+			cli.IntFlag{
+				Name:  "n",
+				Usage: "number of shares of the old committee (defaults to the key share's)",
+			},
+			cli.IntFlag{
+				Name:  "t",
+				Usage: "threshold of the old committee (defaults to the key share's)",
+			},
+			cli.IntFlag{
+				Name:  "new-n",
+				Value: 3,
+				Usage: "number of shares of the new committee",
+			},
+			cli.IntFlag{
+				Name:  "new-t",
+				Value: 2,
+				Usage: "threshold of the new committee",
+			},
+			cli.StringFlag{
+				Name:  "key",
+				Usage: "name of this peer's old key share in the keystore (instead of -k)",
+			},
+			cli.StringFlag{
+				Name:  "save",
+				Usage: "store the new key share in the keystore under this name instead of printing it",
+			},
+			preparamsFlag(),
+			preparamsTimeoutFlag(),
+		}, append(append(identityFlags(), keystoreFlags()...), lobbyFlags()...)...),
 		Action: func(c *cli.Context) error {
+			partyBusUrl := c.String("bus")
+			sessionId := c.String("s")
+			partyId := c.String("p")
+			partycount := c.Int("n")
+			threshold := c.Int("t")
+			newPartycount := c.Int("new-n")
+			newThreshold := c.Int("new-t")
+			if threshold > partycount || newThreshold > newPartycount {
+				return fmt.Errorf("threshold (t) must be lower than party count (n)")
+			}
+
+			keyShare, err := readKeyShare(c)
+			if err != nil {
+				return err
+			}
+			eddsa := c.Bool("eddsa")
+			if keyShare != "" {
+				eddsa, err = isEddsaKeyShare(c, keyShare)
+				if err != nil {
+					return err
+				}
+			}
+
+			proposal, err := coordinateResharing(c, partyId, keyShare, eddsa)
+			if err != nil {
+				return err
+			}
+			if proposal != nil {
+				sessionId, partycount, threshold = proposal.Id, proposal.N, proposal.T
+				newPartycount, newThreshold = proposal.NewN, proposal.NewT
+			}
+
+			if keyShare == "" {
+				if partycount == 0 || threshold == 0 {
+					return fmt.Errorf("n and t of the old committee are required to join the new committee")
+				}
+				if partyId == "" {
+					partyId = namegen.New().Get()
+				}
+			}
+
+			if c.Bool("preparams") && (eddsa || keyShare != "") {
+				return fmt.Errorf("preparams are only used by ecdsa parties of the new committee")
+			}
+			if keyShare == "" {
+				err = checkSaveKeyShare(c)
+				if err != nil {
+					return err
+				}
+			}
+
+			var tssParty tssparty.ResharingTssParty
+			if eddsa {
+				tssParty, err = tssparty.NewEddsaResharingTssParty(partyId, keyShare, partycount, threshold, newPartycount, newThreshold)
+			} else if c.Bool("preparams") {
+				var preParams string
+				preParams, err = takePreParams(c)
+				if err != nil {
+					return err
+				}
+				tssParty, err = tssparty.NewEcdsaResharingTssPartyWithPreParams(partyId, preParams, partycount, threshold, newPartycount, newThreshold)
+			} else {
+				tssParty, err = tssparty.NewEcdsaResharingTssParty(partyId, keyShare, partycount, threshold, newPartycount, newThreshold)
+			}
+			if err != nil {
+				return err
+			}
+
+			tssParty.SetTimeouts(tssparty.Timeouts{PreParams: c.Duration("preparams-timeout")})
+			err = setupIdentity(c, tssParty)
+			if err != nil {
+				return err
+			}
+
+			newKeyShare, err := tssparty.ConnectAndReshareKey(tssParty, partyBusUrl, sessionId)
+			if err != nil {
+				return err
+			}
+			if newKeyShare != "" && c.String("save") != "" {
+				return saveKeyShare(c, newKeyShare)
+			}
+			if newKeyShare != "" {
+				fmt.Printf("%s\n", newKeyShare)
+			}
 			return nil
 		},
 	}
 }
+
+// coordinateResharing proposes the resharing of the key share in the lobby, or waits for a proposal to
+// reshare the key share, or any key when joining the new committee, it returns nil without lobby
+func coordinateResharing(c *cli.Context, partyId string, keyShare string, eddsa bool) (*tssparty.Proposal, error) {
+	if c.String("lobby") == "" && !c.Bool("propose") {
+		return nil, nil
+	}
+	curve := tssparty.CurveSecp256k1
+	if eddsa {
+		curve = tssparty.CurveEd25519
+	}
+	local := tssparty.Proposal{
+		Protocol: tssparty.ProtocolResharing,
+		Curve:    curve,
+		N:        c.Int("n"),
+		T:        c.Int("t"),
+		NewN:     c.Int("new-n"),
+		NewT:     c.Int("new-t"),
+	}
+	if keyShare != "" {
+		share, err := tssparty.ParseKeyShare(keyShare)
+		if err != nil {
+			return nil, err
+		}
+		if share.Version == 0 {
+			return nil, fmt.Errorf("a key share with metadata is required to join a lobby")
+		}
+		if partyId == "" {
+			partyId = share.PartyId
+		}
+		local.N, local.T, local.PublicKey = share.N, share.T, share.PublicKey
+	}
+
+	return coordinate(c, partyId, local, func(proposal tssparty.Proposal) error {
+		if keyShare != "" && !strings.EqualFold(proposal.PublicKey, local.PublicKey) {
+			return fmt.Errorf("proposed key %s is not the key of the local share", proposal.PublicKey)
+		}
+		if keyShare != "" && (proposal.N != local.N || proposal.T != local.T) {
+			return fmt.Errorf("proposed n=%v t=%v but the local share has n=%v t=%v", proposal.N, proposal.T, local.N, local.T)
+		}
+		return errors.Join(checkProposedCurve(proposal, eddsa), checkProposedCount(c, "n", proposal.N), checkProposedCount(c, "t", proposal.T),
+			checkProposedCount(c, "new-n", proposal.NewN), checkProposedCount(c, "new-t", proposal.NewT))
+	})
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"io"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/anandvarma/namegen"
 	"github.com/swarmlab-dev/go-tss/tssparty"
@@ -15,7 +20,7 @@ func signingCmd() cli.Command {
 		Name:    "signing",
 		Aliases: []string{"s"},
 		Usage:   "Signing threshold ceremony to sign a message",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			cli.StringFlag{
 				Name:  "bus",
 				Value: "127.0.0.1:8080",
@@ -28,36 +33,76 @@ func signingCmd() cli.Command {
 			},
 			cli.StringFlag{
 				Name:  "p",
-				Usage: "this peer id",
-				Value: namegen.New().Get(),
+				Usage: "this peer id (defaults to the key share's)",
 			},
 			cli.StringFlag{
 				Name:  "k",
 				Usage: "this peer's key share",
-				Value: namegen.New().Get(),
 			},
 			cli.BoolFlag{
 				Name:  "eddsa",
-				Usage: "set keygen for eddsa (default is ecdsa)",
+				Usage: "set signing for eddsa (default is the key share's curve, or ecdsa)",
 			},
 			cli.IntFlag{
 				Name:  "n",
-				Value: 3,
-				Usage: "number of shares",
+				Usage: "number of shares (defaults to the key share's)",
 			},
 			cli.IntFlag{
 				Name:  "t",
-				Value: 2,
-				Usage: "number of party necessary to sign (threshold)",
+				Usage: "number of party necessary to sign (threshold, defaults to the key share's)",
 			},
 			cli.StringFlag{
 				Name:  "msg",
 				Value: "",
 				Usage: "message to sign with threshold algorithm",
 			},
-		},
+			cli.StringFlag{
+				Name:  "batch",
+				Usage: "file of messages to sign in one session, one per line (instead of --msg), the signatures are printed one per line in the same order",
+			},
+			cli.BoolFlag{
+				Name:  "hex",
+				Usage: "--msg and the lines of --batch are hex encoded bytes, such as unsigned ethereum transactions",
+			},
+			cli.StringFlag{
+				Name:  "hash",
+				Usage: "how the message is hashed: digest-hex, digest-base64, sha256, keccak256, sha256d, sha512 or eddsa (default is sha256 for ecdsa, eddsa for eddsa), eddsa cannot sign a message or digest starting with a zero byte",
+			},
+			cli.StringFlag{
+				Name:  "format",
+				Value: string(tssparty.SignatureFormatJson),
+				Usage: "signature output format: json, eth, der, compact or ed25519 (binary formats are printed in hex)",
+			},
+			cli.Uint64Flag{
+				Name:  "chain-id",
+				Usage: "EIP-155 chain id of the eth signature format",
+			},
+			cli.StringFlag{
+				Name:  "key",
+				Usage: "name of this peer's key share in the keystore (instead of -k)",
+			},
+			cli.StringFlag{
+				Name:  "path",
+				Usage: "BIP32 non-hardened derivation path of the child key to sign with, such as m/0/1 (ecdsa key shares with a chain code)",
+			},
+			cli.StringFlag{
+				Name:  "signers",
+				Usage: "comma separated ids of the holders to select first as signers, the same on all participants (default is the order of the key share's roster)",
+			},
+		}, append(append(identityFlags(), keystoreFlags()...), lobbyFlags()...)...),
 		Action: func(c *cli.Context) error {
-			msg := c.String("msg")
+			msg, err := hexMessage(c, c.String("msg"))
+			if err != nil {
+				return err
+			}
+			msgs, err := readBatch(c)
+			if err != nil {
+				return err
+			}
+			format, err := tssparty.ParseSignatureFormat(c.String("format"))
+			if err != nil {
+				return err
+			}
 			partyBusUrl := c.String("bus")
 			sessionId := c.String("s")
 			partyId := c.String("p")
@@ -67,18 +112,34 @@ func signingCmd() cli.Command {
 				return fmt.Errorf("threshold (t) must be lower than party count (n)")
 			}
 
-			keyShare := c.String("k")
-			if keyShare == "-" {
-				keyShareB, err := io.ReadAll(os.Stdin)
-				if err != nil {
-					return err
+			keyShare, err := readKeyShare(c)
+			if err != nil {
+				return err
+			}
+			eddsa, err := isEddsaKeyShare(c, keyShare)
+			if err != nil {
+				return err
+			}
+
+			hashMode, path, signers := c.String("hash"), c.String("path"), c.String("signers")
+			given := msgs
+			if given == nil && msg != "" {
+				given = []string{msg}
+			}
+			proposal, err := coordinateSigning(c, partyId, keyShare, eddsa, given)
+			if err != nil {
+				return err
+			}
+			if proposal != nil {
+				sessionId, msgs = proposal.Id, make([]string, len(proposal.Messages))
+				for i, proposed := range proposal.Messages {
+					msgs[i] = string(proposed)
 				}
-				keyShare = string(keyShareB)
+				hashMode, path, signers = string(proposal.HashMode), proposal.Path, strings.Join(proposal.Accepted, ",")
 			}
 
 			var tssParty tssparty.SigningTssParty
-			var err error
-			if c.Bool("eddsa") {
+			if eddsa {
 				tssParty, err = tssparty.NewEddsaSigningTssParty(partyId, keyShare, partycount, threshold)
 			} else {
 				tssParty, err = tssparty.NewEcdsaSigningTssParty(partyId, keyShare, partycount, threshold)
@@ -87,12 +148,169 @@ func signingCmd() cli.Command {
 				return err
 			}
 
-			signedMsg, err := tssparty.ConnectAndSignMessage(tssParty, partyBusUrl, sessionId, msg)
+			if hashMode != "" {
+				err = tssParty.SetHashMode(tssparty.HashMode(hashMode))
+				if err != nil {
+					return err
+				}
+			}
+
+			if path != "" {
+				err = tssParty.SetDerivationPath(path)
+				if err != nil {
+					return err
+				}
+			}
+
+			if signers != "" {
+				err = tssParty.SetSignerPreference(strings.Split(signers, ","))
+				if err != nil {
+					return err
+				}
+			}
+
+			err = setupIdentity(c, tssParty)
+			if err != nil {
+				return err
+			}
+
+			// a proposal accepted in a lobby was already shown
+			if proposal == nil && !c.Bool("yes") {
+				err = checkOperator(c)
+				if err != nil {
+					return err
+				}
+				err = tssParty.SetApproval(confirmSigning)
+				if err != nil {
+					return err
+				}
+			}
+
+			var signedMsgs []string
+			if msgs != nil {
+				signedMsgs, err = tssparty.ConnectAndSignMessages(tssParty, partyBusUrl, sessionId, msgs)
+			} else {
+				var signedMsg string
+				signedMsg, err = tssparty.ConnectAndSignMessage(tssParty, partyBusUrl, sessionId, msg)
+				signedMsgs = []string{signedMsg}
+			}
+			if errors.Is(err, tssparty.ErrStandBy) {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				return nil
+			}
 			if err != nil {
 				return err
 			}
-			fmt.Printf("%s\n", signedMsg)
+			for _, signedMsg := range signedMsgs {
+				if format == tssparty.SignatureFormatJson {
+					fmt.Printf("%s\n", signedMsg)
+					continue
+				}
+				signature, err := tssparty.EncodeSignature(signedMsg, format, c.Uint64("chain-id"))
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s\n", hex.EncodeToString(signature))
+			}
 			return nil
 		},
 	}
 }
+
+// confirmSigning shows the operator what is about to be signed once the signers are selected, and asks whether to sign
+func confirmSigning(ctx context.Context, intent tssparty.SigningIntent) error {
+	fmt.Fprintf(os.Stderr, "\nsigning in session %s on %s\n", intent.Session, intent.Curve)
+	if intent.Path != "" {
+		fmt.Fprintf(os.Stderr, "  child key %s: %s\n", intent.Path, intent.PublicKey)
+	} else {
+		fmt.Fprintf(os.Stderr, "  public key: %s\n", intent.PublicKey)
+	}
+	cosigners := slices.DeleteFunc(slices.Clone(intent.Signers), func(id string) bool { return id == intent.PartyId })
+	fmt.Fprintf(os.Stderr, "  co-signers: %s\n", strings.Join(cosigners, ", "))
+	printMessages(intent.HashMode, intent.Messages, intent.Digests)
+	return askOperator(ctx, "sign?")
+}
+
+// coordinateSigning proposes the signing of the messages in the lobby, or waits for a proposal to sign
+// with the key share, it returns nil without lobby
+func coordinateSigning(c *cli.Context, partyId string, keyShare string, eddsa bool, msgs []string) (*tssparty.Proposal, error) {
+	if c.String("lobby") == "" && !c.Bool("propose") {
+		return nil, nil
+	}
+	share, err := tssparty.ParseKeyShare(keyShare)
+	if err != nil {
+		return nil, err
+	}
+	if share.Version == 0 {
+		return nil, fmt.Errorf("a key share with metadata is required to join a lobby")
+	}
+	if partyId == "" {
+		partyId = share.PartyId
+	}
+	if c.Bool("propose") && len(msgs) == 0 {
+		return nil, fmt.Errorf("--msg or --batch is required to propose a signing")
+	}
+
+	given := make([][]byte, len(msgs))
+	for i, msg := range msgs {
+		given[i] = []byte(msg)
+	}
+	local := tssparty.Proposal{
+		Protocol:  tssparty.ProtocolSigning,
+		Curve:     share.Curve,
+		N:         share.N,
+		T:         share.T,
+		PublicKey: share.PublicKey,
+		HashMode:  tssparty.HashMode(c.String("hash")),
+		Path:      c.String("path"),
+		Messages:  given,
+	}
+	return coordinate(c, partyId, local, func(proposal tssparty.Proposal) error {
+		if !strings.EqualFold(proposal.PublicKey, share.PublicKey) {
+			return fmt.Errorf("proposed key %s is not the key of the local share", proposal.PublicKey)
+		}
+		if len(given) > 0 && !slices.EqualFunc(proposal.Messages, given, bytes.Equal) {
+			return fmt.Errorf("proposed messages differ from the given ones")
+		}
+		return errors.Join(checkProposedCurve(proposal, eddsa), checkProposedCount(c, "n", proposal.N), checkProposedCount(c, "t", proposal.T))
+	})
+}
+
+// readBatch reads the messages of the --batch file, nil without the flag
+func readBatch(c *cli.Context) ([]string, error) {
+	path := c.String("batch")
+	if path == "" {
+		return nil, nil
+	}
+	if c.String("msg") != "" {
+		return nil, fmt.Errorf("--msg and --batch cannot be used together")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read batch file: %w", err)
+	}
+	msgs := strings.Split(strings.TrimRight(string(content), "\r\n"), "\n")
+	for i, msg := range msgs {
+		msgs[i], err = hexMessage(c, strings.TrimSuffix(msg, "\r"))
+		if err != nil {
+			return nil, fmt.Errorf("line %v of the batch file: %w", i+1, err)
+		}
+	}
+	if len(msgs) == 1 && msgs[0] == "" {
+		return nil, fmt.Errorf("batch file %s holds no message", path)
+	}
+	return msgs, nil
+}
+
+// hexMessage decodes a message given in hex with --hex, other messages are signed as they are given
+func hexMessage(c *cli.Context, msg string) (string, error) {
+	if !c.Bool("hex") {
+		return msg, nil
+	}
+	decoded, err := hex.DecodeString(strings.TrimPrefix(msg, "0x"))
+	if err != nil {
+		return "", fmt.Errorf("invalid hex message: %w", err)
+	}
+	return string(decoded), nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/swarmlab-dev/go-tss/tssparty"
+)
+
+// printTransaction shows the fields of an ethereum transaction about to be signed
+func printTransaction(tx *tssparty.EthereumTransaction, size int) {
+	fmt.Fprintf(os.Stderr, "  message ethereum %s transaction (%v bytes)\n", tx.Type, size)
+	if tx.ChainId != nil {
+		fmt.Fprintf(os.Stderr, "    chain id: %s\n", tx.ChainId)
+	} else {
+		fmt.Fprintf(os.Stderr, "    chain id: none, the transaction can be replayed on any chain\n")
+	}
+	fmt.Fprintf(os.Stderr, "    nonce: %s\n", tx.Nonce)
+	if tx.To != "" {
+		fmt.Fprintf(os.Stderr, "    to: %s\n", tx.To)
+	} else {
+		fmt.Fprintf(os.Stderr, "    to: none, the transaction creates a contract\n")
+	}
+	fmt.Fprintf(os.Stderr, "    value: %s ether\n", formatUnits(tx.Value, 18))
+	fmt.Fprintf(os.Stderr, "    gas limit: %s\n", tx.GasLimit)
+	if tx.GasPrice != nil {
+		fmt.Fprintf(os.Stderr, "    gas price: %s gwei\n", formatUnits(tx.GasPrice, 9))
+	} else {
+		fmt.Fprintf(os.Stderr, "    max fee per gas: %s gwei\n", formatUnits(tx.MaxFeePerGas, 9))
+		fmt.Fprintf(os.Stderr, "    max priority fee per gas: %s gwei\n", formatUnits(tx.MaxPriorityFeePerGas, 9))
+	}
+	if len(tx.Data) > 0 {
+		fmt.Fprintf(os.Stderr, "    data: 0x%s (%v bytes)\n", hex.EncodeToString(tx.Data), len(tx.Data))
+	}
+	if tx.AccessList > 0 {
+		fmt.Fprintf(os.Stderr, "    access list: %v entries\n", tx.AccessList)
+	}
+}
+
+// formatUnits writes an amount of the smallest unit in a unit of 10^decimals of them, such as wei in ether
+func formatUnits(amount *big.Int, decimals int) string {
+	digits := amount.String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-decimals], strings.TrimRight(digits[len(digits)-decimals:], "0")
+	if fraction == "" {
+		return whole
+	}
+	return whole + "." + fraction
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/swarmlab-dev/go-tss/tssparty"
+	"github.com/urfave/cli"
+)
+
+func verifyCmd() cli.Command {
+	return cli.Command{
+		Name:  "verify",
+		Usage: "Verify a signature against the public key of a key share",
+		Flags: append([]cli.Flag{
+			cli.StringFlag{
+				Name:  "k",
+				Usage: "key share holding the public key (- to read it from stdin)",
+			},
+			cli.StringFlag{
+				Name:  "key",
+				Usage: "name of the key share in the keystore (instead of -k)",
+			},
+			cli.StringFlag{
+				Name:  "pubkey",
+				Usage: "hex public key, SEC1 for ecdsa or 32 bytes for eddsa (instead of a key share)",
+			},
+			cli.BoolFlag{
+				Name:  "eddsa",
+				Usage: "the public key is an eddsa one (default is guessed from its length)",
+			},
+			cli.StringFlag{
+				Name:  "msg",
+				Usage: "signed message",
+			},
+			cli.BoolFlag{
+				Name:  "hex",
+				Usage: "--msg is hex encoded bytes",
+			},
+			cli.StringFlag{
+				Name:  "signature",
+				Usage: "json output of signing, or a hex signature",
+			},
+			cli.StringFlag{
+				Name:  "hash",
+				Usage: "how the message was hashed (default is the signature's, or the curve's default)",
+			},
+			cli.StringFlag{
+				Name:  "path",
+				Usage: "BIP32 derivation path of the child key of the key share that signed (default is the signature's)",
+			},
+		}, keystoreFlags()...),
+		Action: func(c *cli.Context) error {
+			msg, err := hexMessage(c, c.String("msg"))
+			if err != nil {
+				return err
+			}
+			sig := strings.TrimSpace(c.String("signature"))
+			var parsed *tssparty.Signature
+			if strings.HasPrefix(sig, "{") {
+				parsed, err = tssparty.JsonToSignature(sig)
+				if err != nil {
+					return err
+				}
+			}
+
+			path := c.String("path")
+			if path == "" && parsed != nil {
+				path = parsed.Path
+			}
+			publicKey, curve, err := readPublicKey(c, path)
+			if err != nil {
+				return err
+			}
+
+			hashMode := tssparty.DefaultHashMode(curve)
+			var signature []byte
+			if parsed != nil {
+				if parsed.Curve != "" && parsed.Curve != curve {
+					return fmt.Errorf("signature is for curve %s but the public key is for %s", parsed.Curve, curve)
+				}
+				if parsed.HashMode != "" {
+					hashMode = parsed.HashMode
+				}
+				signature = parsed.Signature
+				if c.String("hash") != "" {
+					hashMode = tssparty.HashMode(c.String("hash"))
+				}
+
+				// the signed value is part of the output, point at the message when it differs
+				digest, err := tssparty.HashMessage([]byte(msg), hashMode, curve)
+				if err != nil {
+					return err
+				}
+				if parsed.M != nil && !bytes.Equal(bytes.TrimLeft(digest, "\x00"), bytes.TrimLeft(parsed.M, "\x00")) {
+					return fmt.Errorf("signature was made over a different message (or hash mode)")
+				}
+			} else {
+				signature, err = hex.DecodeString(strings.TrimPrefix(sig, "0x"))
+				if err != nil {
+					return fmt.Errorf("cannot decode hex signature: %w", err)
+				}
+				if c.String("hash") != "" {
+					hashMode = tssparty.HashMode(c.String("hash"))
+				}
+			}
+
+			err = tssparty.VerifySignature(publicKey, []byte(msg), signature, curve, hashMode)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("signature is valid\n")
+			return nil
+		},
+	}
+}
+
+// readPublicKey returns the public key given with --pubkey or held by the key share, derived at path
+// if any, along with its curve. A public key given with --pubkey is taken as the signing key itself.
+func readPublicKey(c *cli.Context, path string) ([]byte, string, error) {
+	if pubkey := c.String("pubkey"); pubkey != "" {
+		if c.String("path") != "" {
+			return nil, "", fmt.Errorf("--path derives the key of a key share, it cannot be used with --pubkey")
+		}
+		publicKey, err := hex.DecodeString(strings.TrimPrefix(pubkey, "0x"))
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot decode hex public key: %w", err)
+		}
+		if c.Bool("eddsa") || len(publicKey) == 32 {
+			return publicKey, tssparty.CurveEd25519, nil
+		}
+		return publicKey, tssparty.CurveSecp256k1, nil
+	}
+
+	keyShare, err := readKeyShare(c)
+	if err != nil {
+		return nil, "", err
+	}
+	if keyShare == "" {
+		return nil, "", fmt.Errorf("a key share or a public key is required")
+	}
+	share, err := tssparty.ParseKeyShare(keyShare)
+	if err != nil {
+		return nil, "", err
+	}
+	if share.Version == 0 {
+		return nil, "", fmt.Errorf("key share does not record its public key, use --pubkey")
+	}
+	if path != "" {
+		pub, _, err := keySharePublicKey(keyShare, path)
+		if err != nil {
+			return nil, "", err
+		}
+		return pub.Bytes(), pub.Curve, nil
+	}
+	publicKey, err := hex.DecodeString(share.PublicKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return publicKey, share.Curve, nil
+}
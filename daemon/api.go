@@ -0,0 +1,159 @@
+package daemon
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/swarmlab-dev/go-tss/keystore"
+)
+
+// Handler serves the API of the daemon:
+//
+//	GET    /keys         key shares held by the daemon
+//	POST   /keygen       join a keygen session, body is a KeygenRequest
+//	POST   /signing      join a signing session or wait for a proposal in a lobby, body is a SigningRequest
+//	POST   /resharing    join a resharing session, body is a ResharingRequest
+//	GET    /jobs         every job
+//	GET    /jobs/{id}    state and result of a job
+//	DELETE /jobs/{id}    cancel a running job
+func (daemon *Daemon) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/keys", daemon.method(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, daemon.Keys())
+	}))
+	mux.HandleFunc("/keygen", daemon.method(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		var request KeygenRequest
+		if readJson(w, r, &request) {
+			job, err := daemon.Keygen(request)
+			writeJob(w, job, err)
+		}
+	}))
+	mux.HandleFunc("/signing", daemon.method(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		var request SigningRequest
+		if readJson(w, r, &request) {
+			request.Requester = requester(r)
+			job, err := daemon.Sign(request)
+			writeJob(w, job, err)
+		}
+	}))
+	mux.HandleFunc("/resharing", daemon.method(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		var request ResharingRequest
+		if readJson(w, r, &request) {
+			job, err := daemon.Reshare(request)
+			writeJob(w, job, err)
+		}
+	}))
+	mux.HandleFunc("/jobs", daemon.method(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, daemon.Jobs())
+	}))
+	mux.HandleFunc("/jobs/", daemon.authorized(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodDelete:
+			err = daemon.CancelJob(id)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		if err != nil {
+			writeError(w, errorStatus(err), err)
+			return
+		}
+		job, err := daemon.Job(id)
+		if err != nil {
+			writeError(w, errorStatus(err), err)
+			return
+		}
+		writeJson(w, http.StatusOK, job)
+	}))
+	return mux
+}
+
+type requesterKey struct{}
+
+// authorized checks the bearer token of the request when the daemon has some, the name
+// of the token is the requester of the request
+func (daemon *Daemon) authorized(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(daemon.config.ApiTokens) > 0 {
+			given, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			var name string
+			var found bool
+			for token, tokenName := range daemon.config.ApiTokens {
+				if subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+					name, found = tokenName, true
+				}
+			}
+			if !found {
+				writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid api token"))
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), requesterKey{}, name))
+		}
+		handler(w, r)
+	}
+}
+
+func requester(r *http.Request) string {
+	name, _ := r.Context().Value(requesterKey{}).(string)
+	return name
+}
+
+func (daemon *Daemon) method(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return daemon.authorized(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		handler(w, r)
+	})
+}
+
+func readJson(w http.ResponseWriter, r *http.Request, request any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJob(w http.ResponseWriter, job Job, err error) {
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJson(w, http.StatusAccepted, job)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrUnknownKey), errors.Is(err, ErrUnknownJob):
+		return http.StatusNotFound
+	case errors.Is(err, keystore.ErrExists):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJson(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJson(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		logger.Errorf("cannot write response: %s", err.Error())
+	}
+}
@@ -0,0 +1,284 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swarmlab-dev/go-tss/keystore"
+	"github.com/swarmlab-dev/go-tss/tssparty"
+)
+
+const testToken = "test-token"
+
+// policyFunc evaluates the signing requests of a test with a function
+type policyFunc func(request PolicyRequest) error
+
+func (policy policyFunc) Evaluate(request PolicyRequest) error {
+	return policy(request)
+}
+
+// newTestServer serves the API of a daemon on its own keystore, connected to the peers of hub
+func newTestServer(t *testing.T, hub *tssparty.MemoryHub, policy Policy) *httptest.Server {
+	t.Helper()
+	ks, err := keystore.Open(filepath.Join(t.TempDir(), "keystore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := New(Config{
+		Keystore:     ks,
+		Password:     []byte("password"),
+		NewTransport: hub.NewTransport,
+		Insecure:     true,
+		Timeouts:     &tssparty.Timeouts{Round: 30 * time.Second},
+		ApiTokens:    map[string]string{testToken: "payments"},
+		Policy:       policy,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(d.Handler())
+	t.Cleanup(func() {
+		server.Close()
+		d.Close()
+	})
+	return server
+}
+
+// call sends a request to the API with token, and decodes its json response into response when not nil
+func call(t *testing.T, server *httptest.Server, token string, method string, path string, body any, response any) int {
+	t.Helper()
+	var reader *bytes.Reader
+	if raw, ok := body.(string); ok {
+		reader = bytes.NewReader([]byte(raw))
+	} else {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	request, err := http.NewRequest(method, server.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if response != nil {
+		err = json.NewDecoder(resp.Body).Decode(response)
+		if err != nil {
+			t.Fatalf("%s %s: %s", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// waitJob polls a job until it is no longer running
+func waitJob(t *testing.T, server *httptest.Server, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(time.Minute)
+	for time.Now().Before(deadline) {
+		var job Job
+		if status := call(t, server, testToken, http.MethodGet, "/jobs/"+id, nil, &job); status != http.StatusOK {
+			t.Fatalf("job %s: status %v", id, status)
+		}
+		if job.Status != JobRunning {
+			return job
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("job %s is still running", id)
+	return Job{}
+}
+
+// startJobs posts a request to each server and waits for the jobs it started
+func startJobs(t *testing.T, servers []*httptest.Server, path string, request func(i int) any) []Job {
+	t.Helper()
+	jobs := make([]Job, len(servers))
+	for i, server := range servers {
+		var job Job
+		if status := call(t, server, testToken, http.MethodPost, path, request(i), &job); status != http.StatusAccepted {
+			t.Fatalf("POST %s to daemon %v: status %v", path, i, status)
+		}
+		jobs[i] = job
+	}
+	for i, server := range servers {
+		jobs[i] = waitJob(t, server, jobs[i].Id)
+	}
+	return jobs
+}
+
+func TestApiTokens(t *testing.T) {
+	server := newTestServer(t, tssparty.NewMemoryHub(), nil)
+	routes := []struct {
+		method, path string
+	}{
+		{http.MethodGet, "/keys"},
+		{http.MethodPost, "/keygen"},
+		{http.MethodPost, "/signing"},
+		{http.MethodPost, "/resharing"},
+		{http.MethodGet, "/jobs"},
+		{http.MethodGet, "/jobs/0123456789abcdef"},
+		{http.MethodDelete, "/jobs/0123456789abcdef"},
+	}
+	for _, route := range routes {
+		for _, token := range []string{"", "wrong-token", testToken + "x"} {
+			var response map[string]string
+			status := call(t, server, token, route.method, route.path, "{}", &response)
+			if status != http.StatusUnauthorized || response["error"] != "invalid api token" {
+				t.Errorf("%s %s with token %q: status %v %v", route.method, route.path, token, status, response)
+			}
+		}
+	}
+
+	var keys []KeyInfo
+	if status := call(t, server, testToken, http.MethodGet, "/keys", nil, &keys); status != http.StatusOK || len(keys) != 0 {
+		t.Fatalf("GET /keys: status %v %v", status, keys)
+	}
+}
+
+func TestApiErrors(t *testing.T) {
+	server := newTestServer(t, tssparty.NewMemoryHub(), nil)
+	tests := []struct {
+		method, path string
+		body         any
+		status       int
+	}{
+		{http.MethodPost, "/keys", nil, http.StatusMethodNotAllowed},
+		{http.MethodGet, "/keygen", nil, http.StatusMethodNotAllowed},
+		{http.MethodPut, "/jobs/0123456789abcdef", nil, http.StatusMethodNotAllowed},
+		{http.MethodPost, "/keygen", "not json", http.StatusBadRequest},
+		{http.MethodPost, "/keygen", `{"session": "s", "unknown": 1}`, http.StatusBadRequest},
+		{http.MethodPost, "/keygen", KeygenRequest{Session: "s", Name: "wallet", PartyId: "p0", N: 3, T: 3}, http.StatusBadRequest},
+		{http.MethodPost, "/signing", SigningRequest{Session: "s", Key: "wallet", Messages: []string{"hello"}}, http.StatusNotFound},
+		{http.MethodPost, "/signing", SigningRequest{Session: "s", Key: "wallet"}, http.StatusBadRequest},
+		{http.MethodGet, "/jobs/0123456789abcdef", nil, http.StatusNotFound},
+		{http.MethodDelete, "/jobs/0123456789abcdef", nil, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		var response map[string]string
+		status := call(t, server, testToken, test.method, test.path, test.body, &response)
+		if status != test.status || response["error"] == "" {
+			t.Errorf("%s %s %v: status %v %v, want %v", test.method, test.path, test.body, status, response, test.status)
+		}
+	}
+
+	// a keygen reserves the name of its key share until it is done
+	keygen := KeygenRequest{Session: "keygen", Name: "wallet", Curve: tssparty.CurveEd25519, PartyId: "p0", N: 3, T: 1}
+	var job Job
+	if status := call(t, server, testToken, http.MethodPost, "/keygen", keygen, &job); status != http.StatusAccepted || job.Status != JobRunning {
+		t.Fatalf("POST /keygen: status %v %+v", status, job)
+	}
+	if status := call(t, server, testToken, http.MethodPost, "/keygen", keygen, nil); status != http.StatusConflict {
+		t.Fatalf("a second keygen of wallet: status %v", status)
+	}
+	var jobs []Job
+	if status := call(t, server, testToken, http.MethodGet, "/jobs", nil, &jobs); status != http.StatusOK || len(jobs) != 1 || jobs[0].Id != job.Id {
+		t.Fatalf("GET /jobs: status %v %+v", status, jobs)
+	}
+	if status := call(t, server, testToken, http.MethodDelete, "/jobs/"+job.Id, nil, nil); status != http.StatusOK {
+		t.Fatalf("DELETE /jobs/%s: status %v", job.Id, status)
+	}
+	if job = waitJob(t, server, job.Id); job.Status != JobCancelled {
+		t.Fatalf("the cancelled keygen ended %+v", job)
+	}
+}
+
+func TestApiKeygenAndSigning(t *testing.T) {
+	hub := tssparty.NewMemoryHub()
+	var lock sync.Mutex
+	var evaluated []PolicyRequest
+	decline := false
+	policy := policyFunc(func(request PolicyRequest) error {
+		lock.Lock()
+		defer lock.Unlock()
+		evaluated = append(evaluated, request)
+		if decline && request.Intent.PartyId == "p1" {
+			return errors.New("denied by the test")
+		}
+		return nil
+	})
+	var servers []*httptest.Server
+	for i := 0; i < 3; i++ {
+		servers = append(servers, newTestServer(t, hub, policy))
+	}
+
+	jobs := startJobs(t, servers, "/keygen", func(i int) any {
+		return KeygenRequest{Session: "keygen", Name: "wallet", Curve: tssparty.CurveEd25519, PartyId: fmt.Sprintf("p%d", i), N: 3, T: 1}
+	})
+	for i, job := range jobs {
+		if job.Status != JobDone || job.Key != "wallet" {
+			t.Fatalf("keygen of daemon %v: %+v", i, job)
+		}
+	}
+	var keys []KeyInfo
+	if status := call(t, servers[2], testToken, http.MethodGet, "/keys", nil, &keys); status != http.StatusOK || len(keys) != 1 || keys[0].PartyId != "p2" {
+		t.Fatalf("GET /keys: status %v %+v", status, keys)
+	}
+	publicKey, err := hex.DecodeString(keys[0].PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the policy approves the signing through the approval of the party
+	signing := func(session string) func(i int) any {
+		return func(i int) any {
+			return SigningRequest{Session: session, Key: "wallet", Messages: []string{"hello world"}}
+		}
+	}
+	jobs = startJobs(t, servers[:2], "/signing", signing("signing"))
+	for i, job := range jobs {
+		signatures, ok := job.Result.([]any)
+		if job.Status != JobDone || !ok || len(signatures) != 1 {
+			t.Fatalf("signing of daemon %v: %+v", i, job)
+		}
+		encoded, err := json.Marshal(signatures[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := tssparty.EncodeSignature(string(encoded), tssparty.SignatureFormatEd25519, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tssparty.VerifySignature(publicKey, []byte("hello world"), signature, tssparty.CurveEd25519, tssparty.HashModeEddsa)
+		if err != nil {
+			t.Fatalf("signature of daemon %v: %s", i, err)
+		}
+	}
+	lock.Lock()
+	if len(evaluated) != 2 {
+		t.Fatalf("the policy evaluated %v requests, want one per signer", len(evaluated))
+	}
+	for _, request := range evaluated {
+		if request.Requester != "payments" || request.Key.Name != "wallet" || len(request.Intent.Messages) != 1 ||
+			string(request.Intent.Messages[0]) != "hello world" || len(request.Intent.Signers) != 2 {
+			t.Fatalf("unexpected policy request %+v", request)
+		}
+	}
+	decline = true
+	lock.Unlock()
+
+	// a signer whose policy declines stops the signing of its peers
+	jobs = startJobs(t, servers[:2], "/signing", signing("declined"))
+	if jobs[1].Status != JobDeclined || !strings.Contains(jobs[1].Error, "denied by the test") {
+		t.Fatalf("the declining daemon ended %+v", jobs[1])
+	}
+	if jobs[0].Status != JobFailed || !strings.Contains(jobs[0].Error, "p1") {
+		t.Fatalf("the peer of the declining daemon ended %+v", jobs[0])
+	}
+}
@@ -0,0 +1,378 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/swarmlab-dev/go-tss/tssparty"
+)
+
+var (
+	ErrUnknownKey = errors.New("no share held for key")
+	ErrUnknownJob = errors.New("unknown job")
+)
+
+type KeygenRequest struct {
+	Session string `json:"session"`
+	Name    string `json:"name"`  // keystore name of the new key share
+	Curve   string `json:"curve"` // secp256k1 (default) or ed25519
+	PartyId string `json:"partyId"`
+	N       int    `json:"n"`
+	T       int    `json:"t"`
+}
+
+type SigningRequest struct {
+	Session  string   `json:"session"`
+	Key      string   `json:"key"` // name or hex public key of the key share
+	Messages []string `json:"messages"`
+	HashMode string   `json:"hashMode,omitempty"`
+	Path     string   `json:"path,omitempty"`    // BIP32 path of the child key to sign with
+	Signers  []string `json:"signers,omitempty"` // holders to select first as signers
+	Lobby    string   `json:"lobby,omitempty"`   // to wait for a signing proposal in, instead of a session
+
+	Requester string `json:"-"` // name of the api token of the request, given to the policy
+}
+
+// ResharingRequest names the key share of a member of the old committee, or the
+// parameters and the name of the new key share of a member of the new committee
+type ResharingRequest struct {
+	Session string `json:"session"`
+	Key     string `json:"key,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Curve   string `json:"curve,omitempty"`
+	PartyId string `json:"partyId,omitempty"`
+	N       int    `json:"n,omitempty"`
+	T       int    `json:"t,omitempty"`
+	NewN    int    `json:"newN"`
+	NewT    int    `json:"newT"`
+}
+
+// Keygen joins a keygen session, the key share is stored under the requested name
+func (daemon *Daemon) Keygen(request KeygenRequest) (Job, error) {
+	if request.Session == "" || request.PartyId == "" {
+		return Job{}, fmt.Errorf("a session and a party id are required")
+	}
+	if request.Curve == "" {
+		request.Curve = tssparty.CurveSecp256k1
+	}
+	if request.Curve != tssparty.CurveSecp256k1 && request.Curve != tssparty.CurveEd25519 {
+		return Job{}, fmt.Errorf("unsupported curve %s", request.Curve)
+	}
+	if request.T >= request.N || request.T < 1 {
+		return Job{}, fmt.Errorf("threshold (t) must be between 1 and party count (n) - 1")
+	}
+	err := daemon.reserveName(request.Name)
+	if err != nil {
+		return Job{}, err
+	}
+
+	party, err := daemon.newKeygenParty(request)
+	if err == nil {
+		err = daemon.setup(party)
+	}
+	if err != nil {
+		daemon.releaseName(request.Name)
+		return Job{}, err
+	}
+
+	return daemon.start(JobKeygen, request.Session, request.Name, func(ctx context.Context) (any, error) {
+		keyShare, err := tssparty.ConnectAndGetKeyShareWithTransportContext(ctx, party, daemon.config.NewTransport(), request.Session)
+		if err != nil {
+			daemon.releaseName(request.Name)
+			return nil, err
+		}
+		return daemon.storeKey(request.Name, keyShare)
+	})
+}
+
+func (daemon *Daemon) newKeygenParty(request KeygenRequest) (tssparty.KeygenTssParty, error) {
+	if request.Curve == tssparty.CurveEd25519 {
+		return tssparty.NewEddsaKeygenTssParty(request.PartyId, request.N, request.T), nil
+	}
+	preParams, err := daemon.takePreParams()
+	if err != nil {
+		return nil, err
+	}
+	if preParams == "" {
+		return tssparty.NewEcdsaKeygenTssParty(request.PartyId, request.N, request.T), nil
+	}
+	return tssparty.NewEcdsaKeygenTssPartyWithPreParams(request.PartyId, preParams, request.N, request.T)
+}
+
+// Sign joins a signing session with the share held for the requested key, the job
+// result lists the signatures in the order of the messages
+func (daemon *Daemon) Sign(request SigningRequest) (Job, error) {
+	if request.Lobby != "" {
+		return daemon.signProposal(request)
+	}
+	if request.Session == "" {
+		return Job{}, fmt.Errorf("a session is required")
+	}
+	if len(request.Messages) == 0 {
+		return Job{}, fmt.Errorf("no message to sign")
+	}
+	held, err := daemon.findKey(request.Key)
+	if err != nil {
+		return Job{}, err
+	}
+
+	party, err := daemon.newSigningParty(held, request.HashMode, request.Path, request.Signers)
+	if err != nil {
+		return Job{}, err
+	}
+	if daemon.config.Policy != nil {
+		key := newKeyInfo(held.name, held.share)
+		err = party.SetApproval(func(ctx context.Context, intent tssparty.SigningIntent) error {
+			return daemon.config.Policy.Evaluate(PolicyRequest{Key: key, Requester: request.Requester, Intent: intent, Time: time.Now()})
+		})
+		if err != nil {
+			return Job{}, err
+		}
+	}
+
+	return daemon.start(JobSigning, request.Session, held.name, func(ctx context.Context) (any, error) {
+		signedMsgs, err := tssparty.ConnectAndSignMessagesWithTransportContext(ctx, party, daemon.config.NewTransport(), request.Session, request.Messages)
+		if err != nil {
+			return nil, err
+		}
+		return signatures(signedMsgs), nil
+	})
+}
+
+// signProposal waits in the lobby of the request for a signing proposal with the requested key. The
+// policy decides whether the daemon accepts it, the proposal is then signed in its session with the
+// participants that accepted it. The session of the job is the lobby.
+func (daemon *Daemon) signProposal(request SigningRequest) (Job, error) {
+	if request.Session != "" || len(request.Messages) > 0 || request.HashMode != "" || request.Path != "" {
+		return Job{}, fmt.Errorf("the session, messages, hash mode and path of a signing in a lobby are the proposed ones")
+	}
+	held, err := daemon.findKey(request.Key)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return daemon.start(JobSigning, request.Lobby, held.name, func(ctx context.Context) (any, error) {
+		proposal, err := daemon.attend(ctx, request.Lobby, held, request.Requester)
+		if err != nil {
+			return nil, err
+		}
+		party, err := daemon.newSigningParty(held, string(proposal.HashMode), proposal.Path, proposal.Accepted)
+		if err != nil {
+			return nil, err
+		}
+		msgs := make([]string, len(proposal.Messages))
+		for i, msg := range proposal.Messages {
+			msgs[i] = string(msg)
+		}
+		signedMsgs, err := tssparty.ConnectAndSignMessagesWithTransportContext(ctx, party, daemon.config.NewTransport(), proposal.Id, msgs)
+		if err != nil {
+			return nil, err
+		}
+		return signatures(signedMsgs), nil
+	})
+}
+
+// attend returns the first signing proposal with a held key that the policy accepted and that started with
+// the daemon. The policy is evaluated once per proposal, when it is accepted rather than before the signing round.
+func (daemon *Daemon) attend(ctx context.Context, lobby string, held *heldKey, requester string) (*tssparty.Proposal, error) {
+	coordinator := tssparty.NewCoordinator(held.share.PartyId)
+	err := daemon.setupIdentity(coordinator)
+	if err != nil {
+		return nil, err
+	}
+	err = coordinator.Connect(daemon.config.NewTransport(), lobby)
+	if err != nil {
+		return nil, err
+	}
+	defer coordinator.Close()
+
+	key := newKeyInfo(held.name, held.share)
+	return coordinator.AttendContext(ctx, func(ctx context.Context, proposal tssparty.Proposal) error {
+		if proposal.Protocol != tssparty.ProtocolSigning {
+			return fmt.Errorf("the daemon waits for a signing proposal")
+		}
+		if !strings.EqualFold(proposal.PublicKey, held.share.PublicKey) {
+			return fmt.Errorf("proposed key %s is not the key of the held share", proposal.PublicKey)
+		}
+		if daemon.config.Policy == nil {
+			return nil
+		}
+		intent, err := proposedIntent(held, proposal)
+		if err != nil {
+			return err
+		}
+		return daemon.config.Policy.Evaluate(PolicyRequest{Key: key, Requester: requester, Proposer: proposal.Proposer, Intent: intent, Time: time.Now()})
+	})
+}
+
+// proposedIntent describes the signing of a proposal for the policy, before its signers are known
+func proposedIntent(held *heldKey, proposal tssparty.Proposal) (tssparty.SigningIntent, error) {
+	publicKey := proposal.PublicKey
+	if proposal.Path != "" {
+		xpub, err := tssparty.KeyShareExtendedPublicKey(held.keyShare)
+		if err != nil {
+			return tssparty.SigningIntent{}, err
+		}
+		child, err := xpub.Derive(proposal.Path)
+		if err != nil {
+			return tssparty.SigningIntent{}, err
+		}
+		childKey, err := child.PublicKey()
+		if err != nil {
+			return tssparty.SigningIntent{}, err
+		}
+		publicKey = childKey.Hex()
+	}
+	intent := tssparty.SigningIntent{
+		Session:   proposal.Id,
+		PartyId:   held.share.PartyId,
+		Curve:     proposal.Curve,
+		HashMode:  proposal.HashMode,
+		Path:      proposal.Path,
+		PublicKey: publicKey,
+		Messages:  proposal.Messages,
+		Digests:   proposal.Digests,
+		Signers:   proposal.Participants,
+	}
+	return intent, nil
+}
+
+// newSigningParty creates a signing party with the held key share
+func (daemon *Daemon) newSigningParty(held *heldKey, hashMode string, path string, signers []string) (tssparty.SigningTssParty, error) {
+	var party tssparty.SigningTssParty
+	var err error
+	if held.share.Curve == tssparty.CurveEd25519 {
+		party, err = tssparty.NewEddsaSigningTssParty("", held.keyShare, 0, 0)
+	} else {
+		party, err = tssparty.NewEcdsaSigningTssParty("", held.keyShare, 0, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hashMode != "" {
+		err = party.SetHashMode(tssparty.HashMode(hashMode))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if path != "" {
+		err = party.SetDerivationPath(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(signers) > 0 {
+		err = party.SetSignerPreference(signers)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return party, daemon.setup(party)
+}
+
+// Reshare joins a resharing session, with the share held for the requested key in the old
+// committee, or in the new committee whose key share is stored under the requested name
+func (daemon *Daemon) Reshare(request ResharingRequest) (Job, error) {
+	if request.Session == "" {
+		return Job{}, fmt.Errorf("a session is required")
+	}
+	if request.NewN == 0 || request.NewT == 0 {
+		return Job{}, fmt.Errorf("the new party count and threshold are required")
+	}
+	if request.Key != "" {
+		return daemon.reshareHeldKey(request)
+	}
+
+	if request.PartyId == "" || request.N == 0 || request.T == 0 {
+		return Job{}, fmt.Errorf("a party id, n and t are required to join the new committee")
+	}
+	if request.Curve == "" {
+		request.Curve = tssparty.CurveSecp256k1
+	}
+	err := daemon.reserveName(request.Name)
+	if err != nil {
+		return Job{}, err
+	}
+
+	var party tssparty.ResharingTssParty
+	if request.Curve == tssparty.CurveEd25519 {
+		party, err = tssparty.NewEddsaResharingTssParty(request.PartyId, "", request.N, request.T, request.NewN, request.NewT)
+	} else if request.Curve != tssparty.CurveSecp256k1 {
+		err = fmt.Errorf("unsupported curve %s", request.Curve)
+	} else {
+		var preParams string
+		preParams, err = daemon.takePreParams()
+		if err == nil && preParams != "" {
+			party, err = tssparty.NewEcdsaResharingTssPartyWithPreParams(request.PartyId, preParams, request.N, request.T, request.NewN, request.NewT)
+		} else if err == nil {
+			party, err = tssparty.NewEcdsaResharingTssParty(request.PartyId, "", request.N, request.T, request.NewN, request.NewT)
+		}
+	}
+	if err == nil {
+		err = daemon.setup(party)
+	}
+	if err != nil {
+		daemon.releaseName(request.Name)
+		return Job{}, err
+	}
+
+	return daemon.start(JobResharing, request.Session, request.Name, func(ctx context.Context) (any, error) {
+		keyShare, err := tssparty.ConnectAndReshareKeyWithTransportContext(ctx, party, daemon.config.NewTransport(), request.Session)
+		if err != nil {
+			daemon.releaseName(request.Name)
+			return nil, err
+		}
+		return daemon.storeKey(request.Name, keyShare)
+	})
+}
+
+// reshareHeldKey hands over the share held for a key to a new committee, the old share is kept
+func (daemon *Daemon) reshareHeldKey(request ResharingRequest) (Job, error) {
+	held, err := daemon.findKey(request.Key)
+	if err != nil {
+		return Job{}, err
+	}
+
+	var party tssparty.ResharingTssParty
+	if held.share.Curve == tssparty.CurveEd25519 {
+		party, err = tssparty.NewEddsaResharingTssParty("", held.keyShare, request.N, request.T, request.NewN, request.NewT)
+	} else {
+		party, err = tssparty.NewEcdsaResharingTssParty("", held.keyShare, request.N, request.T, request.NewN, request.NewT)
+	}
+	if err == nil {
+		err = daemon.setup(party)
+	}
+	if err != nil {
+		return Job{}, err
+	}
+
+	return daemon.start(JobResharing, request.Session, held.name, func(ctx context.Context) (any, error) {
+		_, err := tssparty.ConnectAndReshareKeyWithTransportContext(ctx, party, daemon.config.NewTransport(), request.Session)
+		return nil, err
+	})
+}
+
+// Job returns the state of a job
+func (daemon *Daemon) Job(id string) (Job, error) {
+	job, ok := daemon.jobs.get(id)
+	if !ok {
+		return Job{}, fmt.Errorf("%w: %s", ErrUnknownJob, id)
+	}
+	return job, nil
+}
+
+// Jobs returns the state of every job, oldest first
+func (daemon *Daemon) Jobs() []Job {
+	return daemon.jobs.all()
+}
+
+// CancelJob stops a running job, it then ends as cancelled
+func (daemon *Daemon) CancelJob(id string) error {
+	if !daemon.jobs.cancel(id) {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, id)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package daemon
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-log"
+	"github.com/swarmlab-dev/go-tss/keystore"
+	"github.com/swarmlab-dev/go-tss/tssparty"
+)
+
+var logger = log.Logger("daemon")
+
+// Config of a daemon. The key shares of the keystore are loaded when the daemon starts,
+// the ones produced by its ceremonies are stored there too.
+type Config struct {
+	Keystore *keystore.Keystore
+	Password []byte
+
+	// NewTransport connects the parties of a ceremony to their peers
+	NewTransport func() tssparty.Transport
+
+	// optional
+	IdentityKey   ed25519.PrivateKey
+	Roster        []tssparty.RosterEntry
+	Insecure      bool // runs without roster, the bus can then impersonate the peers
+	Timeouts      *tssparty.Timeouts
+	PreParamsPool *keystore.Pool    // ecdsa preparams taken by keygen and resharing, generated when the pool is empty
+	ApiTokens     map[string]string // bearer tokens accepted by the API, mapped to the name of their requester
+	Policy        Policy            // evaluated before each signing, every signing is allowed without policy
+}
+
+// Daemon runs the ceremonies requested through its API with the key shares it holds
+type Daemon struct {
+	config Config
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock    sync.Mutex
+	keys    map[string]*heldKey
+	pending map[string]bool // names of the key shares being produced
+
+	jobs    jobList
+	running sync.WaitGroup
+}
+
+// heldKey is a key share of the keystore
+type heldKey struct {
+	name     string
+	keyShare string
+	share    *tssparty.KeyShare
+}
+
+// KeyInfo describes a key share held by the daemon, without its secret
+type KeyInfo struct {
+	Name      string    `json:"name"`
+	Curve     string    `json:"curve"`
+	N         int       `json:"n"`
+	T         int       `json:"t"`
+	PartyId   string    `json:"partyId"`
+	Roster    []string  `json:"roster"`
+	PublicKey string    `json:"publicKey"`
+	ChainCode bool      `json:"chainCode"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func newKeyInfo(name string, share *tssparty.KeyShare) KeyInfo {
+	return KeyInfo{
+		Name:      name,
+		Curve:     share.Curve,
+		N:         share.N,
+		T:         share.T,
+		PartyId:   share.PartyId,
+		Roster:    share.Roster,
+		PublicKey: share.PublicKey,
+		ChainCode: share.ChainCode != "",
+		CreatedAt: share.CreatedAt,
+	}
+}
+
+// New loads the key shares of the keystore. Shares without metadata are skipped,
+// they do not tell the parameters a ceremony needs.
+func New(config Config) (*Daemon, error) {
+	if config.Keystore == nil || len(config.Password) == 0 {
+		return nil, fmt.Errorf("a keystore and its password are required")
+	}
+	if config.NewTransport == nil {
+		return nil, fmt.Errorf("a transport is required")
+	}
+	if config.Policy == nil {
+		logger.Warnf("no signing policy, every signing request is signed")
+	}
+	if config.Roster == nil {
+		if !config.Insecure {
+			return nil, fmt.Errorf("a roster is required to authenticate the peers, unless the daemon is insecure")
+		}
+		logger.Warnf("no roster, the peers are not authenticated and the bus can read the private shares sent to them")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	daemon := &Daemon{
+		config:  config,
+		ctx:     ctx,
+		cancel:  cancel,
+		keys:    make(map[string]*heldKey),
+		pending: make(map[string]bool),
+		jobs:    jobList{jobs: make(map[string]*Job)},
+	}
+
+	names, err := config.Keystore.List()
+	if err != nil {
+		return nil, err
+	}
+	var pooled []string
+	if config.PreParamsPool != nil {
+		pooled, err = config.PreParamsPool.Names()
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		if slices.Contains(pooled, name) {
+			continue
+		}
+		secret, err := config.Keystore.Get(name, config.Password)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load key %s: %w", name, err)
+		}
+		share, err := tssparty.ParseKeyShare(string(secret))
+		if err != nil || share.Version == 0 {
+			logger.Warnf("skipping key %s, it is not a key share with metadata", name)
+			continue
+		}
+		daemon.keys[name] = &heldKey{name: name, keyShare: string(secret), share: share}
+		logger.Infof("holding key %s (%s, %v-of-%v, %s)", name, share.Curve, share.T+1, share.N, share.PublicKey)
+	}
+	return daemon, nil
+}
+
+// Close cancels the running jobs and waits for them
+func (daemon *Daemon) Close() {
+	daemon.cancel()
+	daemon.running.Wait()
+}
+
+// Keys describes the key shares held by the daemon
+func (daemon *Daemon) Keys() []KeyInfo {
+	daemon.lock.Lock()
+	defer daemon.lock.Unlock()
+	infos := make([]KeyInfo, 0, len(daemon.keys))
+	for _, key := range daemon.keys {
+		infos = append(infos, newKeyInfo(key.name, key.share))
+	}
+	slices.SortFunc(infos, func(a, b KeyInfo) int { return strings.Compare(a.Name, b.Name) })
+	return infos
+}
+
+// findKey returns the key share held under a name or for a hex public key
+func (daemon *Daemon) findKey(key string) (*heldKey, error) {
+	daemon.lock.Lock()
+	defer daemon.lock.Unlock()
+	if held, ok := daemon.keys[key]; ok {
+		return held, nil
+	}
+	for _, held := range daemon.keys {
+		if strings.EqualFold(held.share.PublicKey, strings.TrimPrefix(key, "0x")) {
+			return held, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownKey, key)
+}
+
+// reserveName makes sure a new key share can be stored under name once its ceremony is done
+func (daemon *Daemon) reserveName(name string) error {
+	if name == "" {
+		return fmt.Errorf("a name is required to store the new key share")
+	}
+	daemon.lock.Lock()
+	defer daemon.lock.Unlock()
+	exists, err := daemon.config.Keystore.Has(name)
+	if err != nil {
+		return err
+	}
+	if exists || daemon.pending[name] {
+		return fmt.Errorf("%w: %s", keystore.ErrExists, name)
+	}
+	daemon.pending[name] = true
+	return nil
+}
+
+func (daemon *Daemon) releaseName(name string) {
+	daemon.lock.Lock()
+	defer daemon.lock.Unlock()
+	delete(daemon.pending, name)
+}
+
+// storeKey saves a new key share under its reserved name and holds it
+func (daemon *Daemon) storeKey(name string, keyShare string) (*KeyInfo, error) {
+	defer daemon.releaseName(name)
+	share, err := tssparty.ParseKeyShare(keyShare)
+	if err != nil {
+		return nil, err
+	}
+	err = daemon.config.Keystore.Put(name, []byte(keyShare), daemon.config.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	daemon.lock.Lock()
+	daemon.keys[name] = &heldKey{name: name, keyShare: keyShare, share: share}
+	daemon.lock.Unlock()
+	logger.Infof("stored key %s (%s)", name, share.PublicKey)
+	info := newKeyInfo(name, share)
+	return &info, nil
+}
+
+// setup applies the identity, roster and timeouts of the daemon to a party
+func (daemon *Daemon) setup(party tssparty.TssParty) error {
+	err := daemon.setupIdentity(party)
+	if err != nil {
+		return err
+	}
+	if daemon.config.Timeouts != nil {
+		party.SetTimeouts(*daemon.config.Timeouts)
+	}
+	return nil
+}
+
+// identityHolder is a party, or the coordinator of a lobby
+type identityHolder interface {
+	SetIdentity(identityKey ed25519.PrivateKey) error
+	SetRoster(roster []tssparty.RosterEntry) error
+	SetInsecure(insecure bool)
+}
+
+// setupIdentity applies the identity and roster of the daemon
+func (daemon *Daemon) setupIdentity(holder identityHolder) error {
+	holder.SetInsecure(daemon.config.Insecure)
+	if daemon.config.IdentityKey != nil {
+		err := holder.SetIdentity(daemon.config.IdentityKey)
+		if err != nil {
+			return err
+		}
+	}
+	if daemon.config.Roster != nil {
+		return holder.SetRoster(daemon.config.Roster)
+	}
+	return nil
+}
+
+// takePreParams returns ecdsa preparams from the pool, empty to let the party generate them
+func (daemon *Daemon) takePreParams() (string, error) {
+	if daemon.config.PreParamsPool == nil {
+		return "", nil
+	}
+	preParams, err := daemon.config.PreParamsPool.Take(daemon.config.Password)
+	if errors.Is(err, keystore.ErrPoolEmpty) {
+		logger.Warnf("preparams pool is empty, generating them")
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot take preparams: %w", err)
+	}
+	return string(preParams), nil
+}
+
+// start runs a ceremony in the background, the job is returned as it starts
+func (daemon *Daemon) start(jobType string, session string, key string, run func(ctx context.Context) (any, error)) (Job, error) {
+	id, err := newJobId()
+	if err != nil {
+		return Job{}, err
+	}
+	ctx, cancel := context.WithCancel(daemon.ctx)
+	job := &Job{
+		Id:        id,
+		Type:      jobType,
+		Session:   session,
+		Key:       key,
+		Status:    JobRunning,
+		CreatedAt: time.Now().UTC(),
+		cancel:    cancel,
+	}
+	daemon.jobs.add(job)
+	logger.Infof("job %s: %s in session %s", id, jobType, session)
+
+	daemon.running.Add(1)
+	go func() {
+		defer daemon.running.Done()
+		defer cancel()
+		result, err := run(ctx)
+		if err != nil {
+			logger.Errorf("job %s: %s", id, err.Error())
+		} else {
+			logger.Infof("job %s: done", id)
+		}
+		daemon.jobs.finish(job, result, err)
+	}()
+	snapshot, _ := daemon.jobs.get(id)
+	return snapshot, nil
+}
+
+// signatures embeds the json signatures in the job result
+func signatures(signedMsgs []string) []json.RawMessage {
+	raw := make([]json.RawMessage, len(signedMsgs))
+	for i, signedMsg := range signedMsgs {
+		raw[i] = json.RawMessage(signedMsg)
+	}
+	return raw
+}
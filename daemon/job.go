@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/swarmlab-dev/go-tss/tssparty"
+)
+
+const (
+	JobKeygen    = "keygen"
+	JobSigning   = "signing"
+	JobResharing = "resharing"
+)
+
+type JobStatus string
+
+const (
+	JobRunning   JobStatus = "running"
+	JobDone      JobStatus = "done"
+	JobFailed    JobStatus = "failed"
+	JobStandBy   JobStatus = "standby"  // a holder not selected to sign
+	JobDeclined  JobStatus = "declined" // a signing denied by the policy
+	JobCancelled JobStatus = "cancelled"
+)
+
+// Job is a ceremony run by the daemon, as reported by the API
+type Job struct {
+	Id         string     `json:"id"`
+	Type       string     `json:"type"`
+	Session    string     `json:"session"`
+	Key        string     `json:"key,omitempty"` // name of the key share in the keystore
+	Status     JobStatus  `json:"status"`
+	Result     any        `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+// jobList holds the jobs of the daemon in the order they were created
+type jobList struct {
+	lock  sync.Mutex
+	jobs  map[string]*Job
+	order []string
+}
+
+func newJobId() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func (list *jobList) add(job *Job) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	list.jobs[job.Id] = job
+	list.order = append(list.order, job.Id)
+}
+
+// get returns a copy of the job, safe to encode while the job runs
+func (list *jobList) get(id string) (Job, bool) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	job, ok := list.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+func (list *jobList) all() []Job {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	jobs := make([]Job, 0, len(list.order))
+	for _, id := range list.order {
+		jobs = append(jobs, *list.jobs[id])
+	}
+	return jobs
+}
+
+func (list *jobList) cancel(id string) bool {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	job, ok := list.jobs[id]
+	if ok && job.Status == JobRunning {
+		job.cancel()
+	}
+	return ok
+}
+
+// finish records the outcome of a job
+func (list *jobList) finish(job *Job, result any, err error) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	now := time.Now().UTC()
+	job.FinishedAt = &now
+	switch {
+	case err == nil:
+		job.Status = JobDone
+		job.Result = result
+	case errors.Is(err, tssparty.ErrStandBy):
+		job.Status = JobStandBy
+		job.Error = err.Error()
+	case errors.Is(err, tssparty.ErrDeclined):
+		job.Status = JobDeclined
+		job.Error = err.Error()
+	case errors.Is(err, context.Canceled):
+		job.Status = JobCancelled
+		job.Error = err.Error()
+	default:
+		job.Status = JobFailed
+		job.Error = err.Error()
+	}
+}
@@ -0,0 +1,239 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swarmlab-dev/go-tss/tssparty"
+)
+
+// Policy decides whether the daemon signs. It is evaluated by each signer on its own before the
+// signing round, so that whoever asks the signers to sign cannot force a signature.
+type Policy interface {
+	Evaluate(request PolicyRequest) error
+}
+
+// PolicyRequest is a signing about to start
+type PolicyRequest struct {
+	Key       KeyInfo
+	Requester string // name of the api token of the request, empty without token
+	Proposer  string // party id of the peer that proposed the signing in a lobby, empty outside a lobby
+	Intent    tssparty.SigningIntent
+	Time      time.Time
+}
+
+const (
+	RuleAllow = "allow"
+	RuleDeny  = "deny"
+)
+
+// Rules is a policy read from a json file. The first rule matching a request decides,
+// a request matching no rule is denied.
+type Rules struct {
+	Rules []*Rule `json:"rules"`
+
+	lock    sync.Mutex
+	history map[rateLimited][]time.Time // signatures allowed by a rule with a rate limit
+}
+
+// rateLimited counts the signatures of a key per rule
+type rateLimited struct {
+	rule *Rule
+	key  string
+}
+
+// Rule matches the requests that meet all of its conditions, a condition left empty matches any request
+type Rule struct {
+	Name       string              `json:"name,omitempty"`
+	Action     string              `json:"action"`               // allow or deny
+	Keys       []string            `json:"keys,omitempty"`       // names or hex public keys of the key shares
+	HashModes  []tssparty.HashMode `json:"hashModes,omitempty"`  // hash modes of the messages
+	Requesters []string            `json:"requesters,omitempty"` // names of the api tokens
+	Proposers  []string            `json:"proposers,omitempty"`  // party ids of the lobby proposers
+	Window     *TimeWindow         `json:"window,omitempty"`
+	RateLimit  *RateLimit          `json:"rateLimit,omitempty"` // for the signatures allowed by the rule
+}
+
+// TimeWindow restricts a rule to some days and hours
+type TimeWindow struct {
+	Days     []string `json:"days,omitempty"`     // mon, tue, wed, thu, fri, sat or sun
+	From     string   `json:"from,omitempty"`     // 15:04, the window goes past midnight when to is earlier
+	To       string   `json:"to,omitempty"`       // 15:04, excluded
+	Location string   `json:"location,omitempty"` // IANA time zone, UTC by default
+
+	days     []time.Weekday
+	from, to time.Duration
+	location *time.Location
+}
+
+// RateLimit bounds the signatures of a key allowed by a rule over a sliding period, beyond it the rule denies
+type RateLimit struct {
+	Signatures int    `json:"signatures"`
+	Period     string `json:"period"` // such as 1h or 24h
+
+	period time.Duration
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// LoadRules reads a policy file
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// ParseRules reads the json rules of a policy
+func ParseRules(data []byte) (*Rules, error) {
+	rules := &Rules{history: make(map[rateLimited][]time.Time)}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(rules)
+	if err != nil {
+		return nil, err
+	}
+	for i, rule := range rules.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("#%d", i+1)
+		}
+		err = rule.compile()
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+	}
+	return rules, nil
+}
+
+func (rule *Rule) compile() error {
+	if rule.Action != RuleAllow && rule.Action != RuleDeny {
+		return fmt.Errorf("action must be %s or %s", RuleAllow, RuleDeny)
+	}
+	if window := rule.Window; window != nil {
+		for _, day := range window.Days {
+			weekday, ok := weekdays[strings.ToLower(day)]
+			if !ok {
+				return fmt.Errorf("unknown day %s", day)
+			}
+			window.days = append(window.days, weekday)
+		}
+		var err error
+		window.from, err = parseTimeOfDay(window.From)
+		if err != nil {
+			return err
+		}
+		window.to, err = parseTimeOfDay(window.To)
+		if err != nil {
+			return err
+		}
+		window.location, err = time.LoadLocation(window.Location)
+		if err != nil {
+			return err
+		}
+	}
+	if limit := rule.RateLimit; limit != nil {
+		period, err := time.ParseDuration(limit.Period)
+		if err != nil || period <= 0 || limit.Signatures < 1 {
+			return fmt.Errorf("a rate limit needs a number of signatures and a period")
+		}
+		limit.period = period
+	}
+	return nil
+}
+
+// parseTimeOfDay reads a 15:04 time as the duration since midnight, empty is midnight
+func parseTimeOfDay(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	at, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %s", value)
+	}
+	return time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute, nil
+}
+
+// Evaluate applies the first rule matching the request
+func (rules *Rules) Evaluate(request PolicyRequest) error {
+	rules.lock.Lock()
+	defer rules.lock.Unlock()
+	for _, rule := range rules.Rules {
+		if !rule.matches(request) {
+			continue
+		}
+		if rule.Action == RuleDeny {
+			return fmt.Errorf("denied by rule %s", rule.Name)
+		}
+		return rules.allow(rule, request)
+	}
+	return fmt.Errorf("no rule allows signing with key %s", request.Key.Name)
+}
+
+func (rule *Rule) matches(request PolicyRequest) bool {
+	if len(rule.Keys) > 0 && !slices.ContainsFunc(rule.Keys, func(key string) bool {
+		return key == request.Key.Name || strings.EqualFold(strings.TrimPrefix(key, "0x"), request.Key.PublicKey)
+	}) {
+		return false
+	}
+	if len(rule.HashModes) > 0 && !slices.Contains(rule.HashModes, request.Intent.HashMode) {
+		return false
+	}
+	if len(rule.Requesters) > 0 && !slices.Contains(rule.Requesters, request.Requester) {
+		return false
+	}
+	if len(rule.Proposers) > 0 && (request.Proposer == "" || !slices.Contains(rule.Proposers, request.Proposer)) {
+		return false
+	}
+	return rule.Window == nil || rule.Window.contains(request.Time)
+}
+
+func (window *TimeWindow) contains(at time.Time) bool {
+	at = at.In(window.location)
+	if len(window.days) > 0 && !slices.Contains(window.days, at.Weekday()) {
+		return false
+	}
+	if window.from == window.to {
+		return true
+	}
+	sinceMidnight := time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+	if window.from < window.to {
+		return sinceMidnight >= window.from && sinceMidnight < window.to
+	}
+	return sinceMidnight >= window.from || sinceMidnight < window.to
+}
+
+// allow records the signatures allowed by a rule, unless they exceed its rate limit
+func (rules *Rules) allow(rule *Rule, request PolicyRequest) error {
+	limit := rule.RateLimit
+	if limit == nil {
+		return nil
+	}
+	id := rateLimited{rule: rule, key: request.Key.PublicKey}
+	recent := slices.DeleteFunc(rules.history[id], func(at time.Time) bool {
+		return at.Add(limit.period).Before(request.Time)
+	})
+	count := len(request.Intent.Messages)
+	if len(recent)+count > limit.Signatures {
+		rules.history[id] = recent
+		return fmt.Errorf("rate limit of rule %s reached, %v signatures allowed every %s", rule.Name, limit.Signatures, limit.Period)
+	}
+	for i := 0; i < count; i++ {
+		recent = append(recent, request.Time)
+	}
+	rules.history[id] = recent
+	return nil
+}
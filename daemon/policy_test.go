@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/swarmlab-dev/go-tss/tssparty"
+)
+
+func TestRulesWindowAndRateLimit(t *testing.T) {
+	rules, err := ParseRules([]byte(`{"rules": [
+		{"name": "weekend", "action": "deny", "window": {"days": ["sat", "sun"]}},
+		{"name": "night", "action": "allow", "window": {"from": "22:00", "to": "06:00", "location": "Europe/Paris"}, "rateLimit": {"signatures": 2, "period": "1h"}}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		at       string
+		messages int
+		allowed  bool
+	}{
+		{"2026-10-17T22:00:00Z", 1, false}, // a saturday
+		{"2026-10-19T12:00:00Z", 1, false}, // a monday, outside of the window
+		{"2026-10-19T21:30:00Z", 1, true},  // 23:30 in Paris
+		{"2026-10-19T21:40:00Z", 1, true},
+		{"2026-10-19T21:50:00Z", 1, false}, // beyond the rate limit
+		{"2026-10-20T03:00:00Z", 2, true},  // 05:00 in Paris, the period passed
+		{"2026-10-20T04:00:00Z", 1, false}, // 06:00 in Paris is excluded
+	}
+	for _, test := range tests {
+		at, err := time.Parse(time.RFC3339, test.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = rules.Evaluate(PolicyRequest{
+			Key:    KeyInfo{Name: "wallet", PublicKey: "02aa"},
+			Intent: tssparty.SigningIntent{Messages: make([][]byte, test.messages)},
+			Time:   at,
+		})
+		if (err == nil) != test.allowed {
+			t.Errorf("%s: allowed %v, got: %v", test.at, test.allowed, err)
+		}
+	}
+}
+
+func TestRuleConditions(t *testing.T) {
+	rules, err := ParseRules([]byte(`{"rules": [
+		{"name": "payments", "action": "allow", "keys": ["wallet"], "requesters": ["payments"], "hashModes": ["keccak256"]},
+		{"name": "treasury", "action": "allow", "keys": ["0x02BB"], "proposers": ["alice"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wallet := KeyInfo{Name: "wallet", PublicKey: "02aa"}
+	treasury := KeyInfo{Name: "treasury", PublicKey: "02bb"}
+	tests := []struct {
+		name    string
+		request PolicyRequest
+		allowed bool
+	}{
+		{"payment", PolicyRequest{Key: wallet, Requester: "payments", Intent: tssparty.SigningIntent{HashMode: tssparty.HashModeKeccak256}}, true},
+		{"payment of another token", PolicyRequest{Key: wallet, Requester: "ops", Intent: tssparty.SigningIntent{HashMode: tssparty.HashModeKeccak256}}, false},
+		{"payment of another hash mode", PolicyRequest{Key: wallet, Requester: "payments", Intent: tssparty.SigningIntent{HashMode: tssparty.HashModeSha256}}, false},
+		{"treasury proposed by alice", PolicyRequest{Key: treasury, Requester: "ops", Proposer: "alice"}, true},
+		{"treasury proposed by bob", PolicyRequest{Key: treasury, Requester: "ops", Proposer: "bob"}, false},
+		{"treasury outside a lobby", PolicyRequest{Key: treasury, Requester: "ops"}, false},
+		{"unknown key", PolicyRequest{Key: KeyInfo{Name: "other", PublicKey: "02cc"}, Requester: "payments", Proposer: "alice"}, false},
+	}
+	for _, test := range tests {
+		test.request.Time = time.Now()
+		err := rules.Evaluate(test.request)
+		if (err == nil) != test.allowed {
+			t.Errorf("%s: allowed %v, got: %v", test.name, test.allowed, err)
+		}
+	}
+}
+
+func TestParseRulesRejects(t *testing.T) {
+	for _, invalid := range []string{
+		`{"rules": [{"action": "maybe"}]}`,
+		`{"rules": [{"action": "allow", "window": {"days": ["someday"]}}]}`,
+		`{"rules": [{"action": "allow", "window": {"from": "25:00"}}]}`,
+		`{"rules": [{"action": "allow", "rateLimit": {"signatures": 1}}]}`,
+		`{"rulez": []}`,
+	} {
+		_, err := ParseRules([]byte(invalid))
+		if err == nil {
+			t.Errorf("accepted %s", invalid)
+		}
+	}
+}
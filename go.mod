@@ -5,8 +5,12 @@ go 1.21.0
 require (
 	github.com/anandvarma/namegen v0.0.0-20230727084436-5197c6ea3255
 	github.com/bnb-chain/tss-lib/v2 v2.0.1
+	github.com/ipfs/go-log v1.0.5
 	github.com/swarmlab-dev/go-partybus v0.0.0-20231002083356-91b18010de54
 	github.com/urfave/cli v1.22.14
+	golang.org/x/crypto v0.13.0
+	golang.org/x/sys v0.12.0
+	google.golang.org/protobuf v1.31.0
 )
 
 require (
@@ -23,7 +27,6 @@ require (
 	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/hashicorp/errwrap v1.0.0 // indirect
 	github.com/hashicorp/go-multierror v1.1.1 // indirect
-	github.com/ipfs/go-log v1.0.5 // indirect
 	github.com/ipfs/go-log/v2 v2.1.3 // indirect
 	github.com/opentracing/opentracing-go v1.2.0 // indirect
 	github.com/otiai10/primes v0.0.0-20210501021515-f1b2be525a11 // indirect
@@ -32,12 +35,9 @@ require (
 	go.uber.org/atomic v1.7.0 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
 	go.uber.org/zap v1.16.0 // indirect
-	golang.org/x/crypto v0.13.0 // indirect
 	golang.org/x/mobile v0.0.0-20230922142353-e2f452493d57 // indirect
 	golang.org/x/mod v0.12.0 // indirect
-	golang.org/x/sys v0.12.0 // indirect
 	golang.org/x/tools v0.13.0 // indirect
-	google.golang.org/protobuf v1.31.0 // indirect
 )
 
 replace github.com/agl/ed25519 => github.com/binance-chain/edwards25519 v0.0.0-20200305024217-f36fc4b53d43
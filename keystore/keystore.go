@@ -0,0 +1,296 @@
+package keystore
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	Version = 1
+
+	fileExt     = ".json"
+	kdfScrypt   = "scrypt"
+	cipherName  = "xchacha20-poly1305"
+	contextInfo = "tssparty keystore v1"
+
+	// scrypt cost, about 32MB of memory per derivation
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	// upper bounds on the cost of a stored file, so that a tampered file cannot exhaust the host
+	maxScryptN      = 1 << 20
+	maxScryptMemory = 1 << 30 // bytes, scrypt takes 128*N*r
+	maxScryptWork   = 1 << 23 // N*r*p, 32 times the default cost
+)
+
+var (
+	ErrNotFound      = errors.New("key not found in keystore")
+	ErrExists        = errors.New("key already exists in keystore")
+	ErrWrongPassword = errors.New("wrong password or corrupted key file")
+)
+
+var nameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// Keystore is a directory of password encrypted secrets, one file per key name
+type Keystore struct {
+	dir string
+}
+
+type scryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+type keyFile struct {
+	Version    int          `json:"version"`
+	Name       string       `json:"name"`
+	Kdf        string       `json:"kdf"`
+	KdfParams  scryptParams `json:"kdfParams"`
+	Salt       []byte       `json:"salt"`
+	Cipher     string       `json:"cipher"`
+	Nonce      []byte       `json:"nonce"`
+	Ciphertext []byte       `json:"ciphertext"`
+}
+
+// Open opens the keystore in dir, creating it if needed. The directory must
+// not be accessible to other users.
+func Open(dir string) (*Keystore, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	err = checkPermissions(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Keystore{dir: dir}, nil
+}
+
+func (ks *Keystore) Dir() string {
+	return ks.dir
+}
+
+// Put encrypts secret with password and stores it under name, an existing key is never overwritten
+func (ks *Keystore) Put(name string, secret []byte, password []byte) error {
+	path, err := ks.path(name)
+	if err != nil {
+		return err
+	}
+	if len(password) == 0 {
+		return fmt.Errorf("empty keystore password")
+	}
+
+	file := keyFile{
+		Version:   Version,
+		Name:      name,
+		Kdf:       kdfScrypt,
+		KdfParams: scryptParams{N: scryptN, R: scryptR, P: scryptP},
+		Salt:      make([]byte, 32),
+		Cipher:    cipherName,
+		Nonce:     make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+
+	aead, err := file.aead(password)
+	if err != nil {
+		return err
+	}
+	file.Ciphertext = aead.Seal(nil, file.Nonce, secret, file.additionalData())
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	return writeNewFile(path, data)
+}
+
+// Get decrypts the secret stored under name
+func (ks *Keystore) Get(name string, password []byte) ([]byte, error) {
+	path, err := ks.path(name)
+	if err != nil {
+		return nil, err
+	}
+	err = checkPermissions(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return readKeyFile(path, name, password)
+}
+
+// readKeyFile decrypts the key file at path, which must hold the key stored under name
+func readKeyFile(path string, name string, password []byte) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file keyFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse key file %s: %w", path, err)
+	}
+	if file.Version != Version || file.Kdf != kdfScrypt || file.Cipher != cipherName {
+		return nil, fmt.Errorf("unsupported key file %s", path)
+	}
+	if file.Name != name {
+		return nil, fmt.Errorf("key file %s holds key %s", path, file.Name)
+	}
+	err = file.KdfParams.check()
+	if err != nil {
+		return nil, fmt.Errorf("key file %s: %w", path, err)
+	}
+
+	aead, err := file.aead(password)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongPassword
+	}
+	secret, err := aead.Open(nil, file.Nonce, file.Ciphertext, file.additionalData())
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return secret, nil
+}
+
+// List returns the names of the stored keys
+func (ks *Keystore) List() ([]string, error) {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), fileExt)
+		if ok && entry.Type().IsRegular() && nameRegexp.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Has tells whether a key is stored under name
+func (ks *Keystore) Has(name string) (bool, error) {
+	path, err := ks.path(name)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete removes the key stored under name
+func (ks *Keystore) Delete(name string) error {
+	path, err := ks.path(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return err
+}
+
+func (ks *Keystore) path(name string) (string, error) {
+	if !nameRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid key name %q", name)
+	}
+	return filepath.Join(ks.dir, name+fileExt), nil
+}
+
+// check bounds the memory and work of the key derivation, the values are divided rather
+// than multiplied not to overflow
+func (params scryptParams) check() error {
+	if params.N <= 1 || params.R <= 0 || params.P <= 0 {
+		return fmt.Errorf("invalid kdf parameters n=%v r=%v p=%v", params.N, params.R, params.P)
+	}
+	if params.N > maxScryptN || params.R > maxScryptMemory/128/params.N || params.P > maxScryptWork/params.N/params.R {
+		return fmt.Errorf("excessive kdf cost n=%v r=%v p=%v", params.N, params.R, params.P)
+	}
+	return nil
+}
+
+func (file *keyFile) aead(password []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(password, file.Salt, file.KdfParams.N, file.KdfParams.R, file.KdfParams.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+// the name and parameters are authenticated along with the secret
+func (file *keyFile) additionalData() []byte {
+	return []byte(fmt.Sprintf("%s\x00%s\x00%s:%d:%d:%d\x00%s", contextInfo, file.Name, file.Kdf, file.KdfParams.N, file.KdfParams.R, file.KdfParams.P, file.Cipher))
+}
+
+// writeNewFile writes data to a private temporary file then links it to path, which fails if path exists
+func writeNewFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = tmp.Chmod(0600)
+	if err == nil {
+		_, err = tmp.Write(data)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Link(tmp.Name(), path)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: %s", ErrExists, strings.TrimSuffix(filepath.Base(path), fileExt))
+	}
+	return err
+}
+
+// checkPermissions refuses files and directories that other users can access
+func checkPermissions(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return fmt.Errorf("permissions %#o of %s are too open, it must not be accessible by other users", perm, path)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+var testPassword = []byte("correct horse battery staple")
+
+func openTestKeystore(t *testing.T) *Keystore {
+	t.Helper()
+	ks, err := Open(filepath.Join(t.TempDir(), "keystore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func TestPutAndGet(t *testing.T) {
+	ks := openTestKeystore(t)
+	secret := []byte("key share")
+	err := ks.Put("wallet", secret, testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ks.Get("wallet", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Fatalf("got %q, want %q", got, secret)
+	}
+	info, err := os.Stat(filepath.Join(ks.Dir(), "wallet.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("key file has permissions %#o", perm)
+	}
+
+	_, err = ks.Get("wallet", []byte("wrong"))
+	if !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("expected ErrWrongPassword, got: %v", err)
+	}
+	_, err = ks.Get("other", testPassword)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got: %v", err)
+	}
+	err = ks.Put("wallet", []byte("another share"), testPassword)
+	if !errors.Is(err, ErrExists) {
+		t.Fatalf("expected ErrExists, got: %v", err)
+	}
+	got, _ = ks.Get("wallet", testPassword)
+	if !bytes.Equal(got, secret) {
+		t.Fatal("the stored key was overwritten")
+	}
+}
+
+func TestListHasAndDelete(t *testing.T) {
+	ks := openTestKeystore(t)
+	for _, name := range []string{"b", "a", "c.1"} {
+		err := ks.Put(name, []byte(name), testPassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// files that are not key files are ignored
+	err := os.WriteFile(filepath.Join(ks.Dir(), "notes.txt"), nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := ks.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, []string{"a", "b", "c.1"}) {
+		t.Fatalf("unexpected keys %v", names)
+	}
+
+	err = ks.Delete("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	has, err := ks.Has("b")
+	if err != nil || has {
+		t.Fatalf("deleted key still exists: %v %v", has, err)
+	}
+	err = ks.Delete("b")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestInvalidNames(t *testing.T) {
+	ks := openTestKeystore(t)
+	for _, name := range []string{"", ".hidden", "../escape", "a/b", "a b"} {
+		err := ks.Put(name, []byte("secret"), testPassword)
+		if err == nil {
+			t.Errorf("stored a key named %q", name)
+		}
+	}
+	err := ks.Put("empty", []byte("secret"), nil)
+	if err == nil {
+		t.Error("stored a key without password")
+	}
+}
+
+func TestTamperedKeyFiles(t *testing.T) {
+	ks := openTestKeystore(t)
+	err := ks.Put("wallet", []byte("key share"), testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(ks.Dir(), "wallet.json")
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(file *keyFile)
+	}{
+		{"renamed key", func(file *keyFile) { file.Name = "other" }},
+		{"changed ciphertext", func(file *keyFile) { file.Ciphertext[0] ^= 1 }},
+		{"weakened kdf", func(file *keyFile) { file.KdfParams.N = 1 << 10 }},
+		{"excessive kdf cost", func(file *keyFile) { file.KdfParams.N = 1 << 30 }},
+		{"excessive kdf memory", func(file *keyFile) { file.KdfParams.N = 1 << 20; file.KdfParams.R = 1 << 20 }},
+		{"excessive kdf work", func(file *keyFile) { file.KdfParams.N = 1 << 20; file.KdfParams.P = 1 << 20 }},
+		{"zero kdf parallelism", func(file *keyFile) { file.KdfParams.P = 0 }},
+		{"unknown cipher", func(file *keyFile) { file.Cipher = "none" }},
+	}
+	for _, test := range tests {
+		var file keyFile
+		err := json.Unmarshal(original, &file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.change(&file)
+		data, err := json.Marshal(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, data, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ks.Get("wallet", testPassword)
+		if err == nil {
+			t.Errorf("%s: the key file was accepted", test.name)
+		}
+	}
+
+	// a key file copied under another name is rejected too
+	err = os.WriteFile(filepath.Join(ks.Dir(), "copy.json"), original, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ks.Get("copy", testPassword)
+	if err == nil {
+		t.Error("a key file was accepted under another name")
+	}
+}
+
+func TestScryptParamsBounds(t *testing.T) {
+	tests := []struct {
+		params scryptParams
+		valid  bool
+	}{
+		{scryptParams{N: scryptN, R: scryptR, P: scryptP}, true},
+		{scryptParams{N: maxScryptN, R: 8, P: 1}, true},
+		{scryptParams{N: 1 << 14, R: 16, P: 4}, true},
+		{scryptParams{N: 0, R: 8, P: 1}, false},
+		{scryptParams{N: scryptN, R: 0, P: 1}, false},
+		{scryptParams{N: scryptN, R: 8, P: -1}, false},
+		{scryptParams{N: maxScryptN * 2, R: 1, P: 1}, false},
+		{scryptParams{N: maxScryptN, R: 16, P: 1}, false},
+		{scryptParams{N: maxScryptN, R: 8, P: 2}, false},
+		{scryptParams{N: 1 << 10, R: 1 << 30, P: 1}, false},
+		{scryptParams{N: 1 << 10, R: 1, P: 1 << 30}, false},
+	}
+	for _, test := range tests {
+		err := test.params.check()
+		if test.valid && err != nil {
+			t.Errorf("%+v: %s", test.params, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%+v: expected the parameters to be rejected", test.params)
+		}
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not checked on windows")
+	}
+	dir := filepath.Join(t.TempDir(), "keystore")
+	ks, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ks.Put("wallet", []byte("key share"), testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chmod(filepath.Join(dir, "wallet.json"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ks.Get("wallet", testPassword)
+	if err == nil {
+		t.Error("read a key file readable by other users")
+	}
+
+	err = os.Chmod(dir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Open(dir)
+	if err == nil {
+		t.Error("opened a keystore accessible by other users")
+	}
+}
@@ -0,0 +1,100 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ErrPoolEmpty = errors.New("pool is empty")
+
+// claimedExt marks a key file being taken from a pool, List ignores it
+const claimedExt = ".taken"
+
+var pooledNameRegexp = regexp.MustCompile(`^[0-9]{20}-[0-9a-f]{8}$`)
+
+// Pool is a set of single-use secrets of a keystore whose names share a prefix, taken oldest first
+type Pool struct {
+	ks     *Keystore
+	prefix string
+}
+
+func (ks *Keystore) Pool(prefix string) (*Pool, error) {
+	if !nameRegexp.MatchString(prefix) {
+		return nil, fmt.Errorf("invalid pool name %q", prefix)
+	}
+	return &Pool{ks: ks, prefix: prefix + "-"}, nil
+}
+
+// Add encrypts secret with password and stores it in the pool under a new name, which is returned
+func (pool *Pool) Add(secret []byte, password []byte) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	// the zero padded timestamp sorts the names by age
+	name := fmt.Sprintf("%s%020d-%s", pool.prefix, time.Now().UnixNano(), hex.EncodeToString(suffix))
+	return name, pool.ks.Put(name, secret, password)
+}
+
+// Names lists the names of the secrets of the pool, oldest first
+func (pool *Pool) Names() ([]string, error) {
+	names, err := pool.ks.List()
+	if err != nil {
+		return nil, err
+	}
+	var pooled []string
+	for _, name := range names {
+		suffix, ok := strings.CutPrefix(name, pool.prefix)
+		if ok && pooledNameRegexp.MatchString(suffix) {
+			pooled = append(pooled, name)
+		}
+	}
+	return pooled, nil
+}
+
+// Take removes the oldest secret from the pool and returns it decrypted. Its key file is
+// claimed by renaming it first, so that concurrent takers never get the same secret.
+func (pool *Pool) Take(password []byte) ([]byte, error) {
+	names, err := pool.Names()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		path, err := pool.ks.path(name)
+		if err != nil {
+			return nil, err
+		}
+		claimed := path + claimedExt
+		err = os.Rename(path, claimed)
+		if errors.Is(err, fs.ErrNotExist) {
+			// taken by someone else meanwhile
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkPermissions(claimed)
+		var secret []byte
+		if err == nil {
+			secret, err = readKeyFile(claimed, name, password)
+		}
+		if err != nil {
+			// give the secret back to the pool
+			if renameErr := os.Rename(claimed, path); renameErr != nil {
+				return nil, errors.Join(err, renameErr)
+			}
+			return nil, err
+		}
+		return secret, os.Remove(claimed)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrPoolEmpty, strings.TrimSuffix(pool.prefix, "-"))
+}
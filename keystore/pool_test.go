@@ -0,0 +1,145 @@
+package keystore
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestPoolTakeOldestFirst(t *testing.T) {
+	ks := openTestKeystore(t)
+	pool, err := ks.Pool("preparams")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ks.Put("wallet", []byte("key share"), testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pool.Take(testPassword)
+	if !errors.Is(err, ErrPoolEmpty) {
+		t.Fatalf("expected ErrPoolEmpty, got: %v", err)
+	}
+	var added []string
+	for i := 0; i < 3; i++ {
+		name, err := pool.Add([]byte(fmt.Sprintf("secret %d", i)), testPassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+		added = append(added, name)
+	}
+	names, err := pool.Names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, added) {
+		t.Fatalf("the pool lists %v, want %v", names, added)
+	}
+
+	for i := 0; i < 3; i++ {
+		secret, err := pool.Take(testPassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(secret) != fmt.Sprintf("secret %d", i) {
+			t.Fatalf("took %q before the older secrets", secret)
+		}
+	}
+	_, err = pool.Take(testPassword)
+	if !errors.Is(err, ErrPoolEmpty) {
+		t.Fatalf("expected ErrPoolEmpty once drained, got: %v", err)
+	}
+	// the other keys of the keystore are not part of the pool
+	if has, err := ks.Has("wallet"); !has || err != nil {
+		t.Fatalf("taking from the pool removed another key: %v", err)
+	}
+
+	// a refill is taken as any other secret
+	_, err = pool.Add([]byte("refill"), testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := pool.Take(testPassword)
+	if err != nil || string(secret) != "refill" {
+		t.Fatalf("took %q from the refilled pool: %v", secret, err)
+	}
+}
+
+func TestPoolTakeGivesBack(t *testing.T) {
+	ks := openTestKeystore(t)
+	pool, err := ks.Pool("preparams")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := pool.Add([]byte("secret"), testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a secret that cannot be decrypted goes back to the pool
+	_, err = pool.Take([]byte("wrong"))
+	if !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("expected ErrWrongPassword, got: %v", err)
+	}
+	names, err := pool.Names()
+	if err != nil || !slices.Equal(names, []string{name}) {
+		t.Fatalf("the secret was not given back to the pool: %v %v", names, err)
+	}
+	secret, err := pool.Take(testPassword)
+	if err != nil || string(secret) != "secret" {
+		t.Fatalf("took %q: %v", secret, err)
+	}
+}
+
+func TestPoolConcurrentTakes(t *testing.T) {
+	ks := openTestKeystore(t)
+	pool, err := ks.Pool("preparams")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const count = 4
+	for i := 0; i < count; i++ {
+		_, err := pool.Add([]byte(fmt.Sprintf("secret %d", i)), testPassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// twice as many takers as secrets, each secret is taken once
+	var lock sync.Mutex
+	var taken []string
+	var empty int
+	var wg sync.WaitGroup
+	for i := 0; i < 2*count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			secret, err := pool.Take(testPassword)
+			lock.Lock()
+			defer lock.Unlock()
+			if errors.Is(err, ErrPoolEmpty) {
+				empty++
+			} else if err != nil {
+				t.Error(err)
+			} else {
+				taken = append(taken, string(secret))
+			}
+		}()
+	}
+	wg.Wait()
+
+	slices.Sort(taken)
+	if len(slices.Compact(taken)) != count || empty != count {
+		t.Fatalf("took %q, %v takers found the pool empty", taken, empty)
+	}
+}
+
+func TestInvalidPoolName(t *testing.T) {
+	ks := openTestKeystore(t)
+	if _, err := ks.Pool("../preparams"); err == nil {
+		t.Fatal("accepted an invalid pool name")
+	}
+}
@@ -0,0 +1,160 @@
+package tssparty
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bnb-chain/tss-lib/v2/crypto"
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+const (
+	confirmationSignatureContext = "tssparty confirmation v1"
+	intentSignatureContext       = "tssparty signing intent v1"
+)
+
+// DisagreementError is returned when some peers did not agree with the local party
+type DisagreementError struct {
+	Step       string
+	Dissenters []string
+}
+
+func (err *DisagreementError) Error() string {
+	return fmt.Sprintf("peers disagree while %s: [ %s ]", err.Step, strings.Join(err.Dissenters, ", "))
+}
+
+// agree broadcasts value and waits for the value of every peer, it fails naming the peers whose value differs
+func (party *tssPartyState) agree(ctx context.Context, msgType peerMessageType, signatureContext string, step string, value []byte) error {
+	ctx, cancel := withTimeout(ctx, party.timeouts.Round)
+	defer cancel()
+
+	err := party.sendSigned(msgType, signatureContext, nil, false, value)
+	if err != nil {
+		return err
+	}
+
+	expected := MapArrayOfPartyID(party.sortedParties, func(p *tss.PartyID) string { return p.Id })
+	values, dissenters, err := party.collect(ctx, msgType, signatureContext, step, expected)
+	if err != nil {
+		return err
+	}
+	var decliners []string
+	for peerId, peerValue := range values {
+		if len(peerValue) == 0 {
+			// an empty value is sent by a signer that declined, see approveSigning
+			decliners = append(decliners, peerId)
+		} else if !bytes.Equal(peerValue, value) {
+			dissenters = append(dissenters, peerId)
+		}
+	}
+
+	if len(decliners) > 0 {
+		sort.Strings(decliners)
+		return &PeerDeclinedError{Signers: decliners}
+	}
+	if len(dissenters) > 0 {
+		sort.Strings(dissenters)
+		return &DisagreementError{Step: step, Dissenters: dissenters}
+	}
+	return nil
+}
+
+// sendSigned signs value for the session and sends it to the peers, all of them when to is empty,
+// encrypted for each recipient when private
+func (party *tssPartyState) sendSigned(msgType peerMessageType, signatureContext string, to []string, private bool, value []byte) error {
+	payload, err := party.signPeerMessage(signatureContext, value)
+	if err != nil {
+		return err
+	}
+	if private {
+		return party.sendPrivate(peerMessage{Type: msgType, Payload: payload}, to)
+	}
+	return party.send(msgType, to, true, payload)
+}
+
+// collect waits for the signed value of every peer in from, the peers whose message
+// cannot be opened are returned apart
+func (party *tssPartyState) collect(ctx context.Context, msgType peerMessageType, signatureContext string, step string, from []string) (map[string][]byte, []string, error) {
+	values := make(map[string][]byte)
+	received := map[string]bool{party.thisParty.Id: true}
+	var rejected []string
+	for len(party.missingPeers(from, received)) > 0 {
+		var msg inboundMessage
+		var ok bool
+		select {
+		case msg, ok = <-party.receive(msgType):
+		case <-ctx.Done():
+			return nil, nil, contextError(ctx, step, party.missingPeers(from, received))
+		}
+		if !ok {
+			missing := party.missingPeers(from, received)
+			return nil, nil, fmt.Errorf("channel closed while %s, missing: [ %s ]", step, strings.Join(missing, ", "))
+		}
+
+		if !slices.Contains(from, msg.From) || received[msg.From] {
+			logger.Errorf("ignoring unexpected message from peer %s while %s", msg.From, step)
+			continue
+		}
+		received[msg.From] = true
+
+		payload := msg.Payload
+		var err error
+		if msg.Encrypted {
+			payload, err = party.decryptFrom(msg.From, msg.Payload)
+		}
+		if err == nil {
+			payload, err = party.openPeerMessage(signatureContext, "message", msg.From, payload)
+		}
+		if err != nil {
+			logger.Errorf("rejecting message from peer %s while %s: %s", msg.From, step, err.Error())
+			rejected = append(rejected, msg.From)
+			continue
+		}
+		values[msg.From] = payload
+	}
+	return values, rejected, nil
+}
+
+// confirmGroupKey checks that every party of the ceremony derived the same group key and chain code for the same committee
+func (party *tssPartyState) confirmGroupKey(ctx context.Context, task string, curve string, n int, t int, committee []*tss.PartyID, publicKey *crypto.ECPoint, chainCode []byte) error {
+	digest, err := json.Marshal(struct {
+		Curve     string   `json:"curve"`
+		N         int      `json:"n"`
+		T         int      `json:"t"`
+		Roster    []string `json:"roster"`
+		PublicKey []byte   `json:"publicKey"`
+		ChainCode []byte   `json:"chainCode,omitempty"`
+	}{curve, n, t, rosterIds(committee), encodePublicKey(curve, publicKey), chainCode})
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(digest)
+	return party.agree(ctx, CONFIRMATION_MESSAGE, confirmationSignatureContext, fmt.Sprintf("confirming the %s group key", task), hash[:])
+}
+
+// confirmSigningIntent checks that every signer is about to sign the same messages the same way with the same key,
+// before any secret dependent message is sent
+func (party *tssPartyState) confirmSigningIntent(ctx context.Context, curve string, hashMode HashMode, messages [][]byte, publicKey *crypto.ECPoint) error {
+	messageHashes := make([][]byte, len(messages))
+	for i, message := range messages {
+		messageHash := sha256.Sum256(message)
+		messageHashes[i] = messageHash[:]
+	}
+	digest, err := json.Marshal(struct {
+		Curve         string   `json:"curve"`
+		HashMode      HashMode `json:"hashMode"`
+		MessageHashes [][]byte `json:"messageHashes"`
+		PublicKey     []byte   `json:"publicKey"`
+	}{curve, hashMode, messageHashes, encodePublicKey(curve, publicKey)})
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(digest)
+	return party.agree(ctx, INTENT_MESSAGE, intentSignatureContext, "agreeing on the message to sign", hash[:])
+}
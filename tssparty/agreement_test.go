@@ -0,0 +1,131 @@
+package tssparty
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tamperAnnouncements rewrites with edit the signed value of the msgType messages of a tamperingTransport,
+// the parties of the tests run without roster so that the signature is not checked
+func tamperAnnouncements(msgType peerMessageType, edit func(value []byte)) func(to []string, msg []byte) []byte {
+	return func(to []string, msg []byte) []byte {
+		var envelope peerMessage
+		var signed signedAnnouncement
+		if json.Unmarshal(msg, &envelope) != nil || envelope.Type != msgType || json.Unmarshal(envelope.Payload, &signed) != nil {
+			return msg
+		}
+		edit(signed.Announcement)
+		payload, err := json.Marshal(signed)
+		if err != nil {
+			panic(err)
+		}
+		envelope.Payload = payload
+		tampered, err := json.Marshal(envelope)
+		if err != nil {
+			panic(err)
+		}
+		return tampered
+	}
+}
+
+func TestGroupKeyMismatch(t *testing.T) {
+	hub := NewMemoryHub()
+	_, errs := runParties(3, func(i int) (string, error) {
+		party, err := newTestKeygenParty(t, CurveEd25519, i, 3, 1)
+		if err != nil {
+			return "", err
+		}
+		transport := hub.NewTransport()
+		if i == 2 {
+			// p2 confirms another group key than the one its peers derived
+			transport = &tamperingTransport{transport, tamperAnnouncements(CONFIRMATION_MESSAGE, func(value []byte) {
+				value[0] ^= 1
+			})}
+		}
+		return ConnectAndGetKeyShareWithTransport(party, transport, "keygen")
+	})
+
+	for i, err := range errs[:2] {
+		var disagreementErr *DisagreementError
+		if !errors.As(err, &disagreementErr) {
+			t.Fatalf("party %v: expected a disagreement, got: %v", i, err)
+		}
+		if disagreementErr.Step != "confirming the keygen group key" || !slices.Equal(disagreementErr.Dissenters, []string{"p2"}) {
+			t.Fatalf("party %v: unexpected disagreement: %s", i, disagreementErr)
+		}
+	}
+}
+
+func TestSigningIntentMismatch(t *testing.T) {
+	hub := NewMemoryHub()
+	shares := testKeygen(t, hub, CurveEd25519, 3, 1)
+	otherShares := testKeygen(t, NewMemoryHub(), CurveEd25519, 3, 1)
+
+	tests := []struct {
+		name     string
+		change   func(party SigningTssParty) (SigningTssParty, string, error) // of the second signer
+		rejected string                                                       // error of both signers, a disagreement when empty
+	}{
+		{"message", func(party SigningTssParty) (SigningTssParty, string, error) {
+			return party, "hello mars", nil
+		}, ""},
+		{"hash mode", func(party SigningTssParty) (SigningTssParty, string, error) {
+			return party, "hello world", party.SetHashMode(HashModeSha256)
+		}, ""},
+		{"public key", func(party SigningTssParty) (SigningTssParty, string, error) {
+			// the share of p1 for another key is caught as soon as the ids are exchanged,
+			// see TestDerivedSigning for signers deriving different keys from the same one
+			party, err := newTestSigningParty(otherShares[1])
+			return party, "hello world", err
+		}, "does not hold the share assigned to it"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var tssMessages atomic.Int32
+			_, errs := runParties(2, func(i int) (string, error) {
+				party, err := newTestSigningParty(shares[i])
+				if err != nil {
+					return "", err
+				}
+				msg := "hello world"
+				if i == 1 {
+					party, msg, err = test.change(party)
+					if err != nil {
+						return "", err
+					}
+				}
+				party.SetTimeouts(Timeouts{Round: 10 * time.Second})
+				transport := &tamperingTransport{hub.NewTransport(), tamperTssMessages(func(to []string, msg *peerMessage) bool {
+					tssMessages.Add(1)
+					return true
+				})}
+				return ConnectAndSignMessageWithTransport(party, transport, "signing-"+test.name, msg)
+			})
+
+			for i, err := range errs {
+				if test.rejected != "" {
+					if err == nil || !strings.Contains(err.Error(), test.rejected) {
+						t.Fatalf("party %v: expected %q, got: %v", i, test.rejected, err)
+					}
+					continue
+				}
+				var disagreementErr *DisagreementError
+				if !errors.As(err, &disagreementErr) {
+					t.Fatalf("party %v: expected a disagreement, got: %v", i, err)
+				}
+				dissenter := []string{"p1", "p0"}[i]
+				if disagreementErr.Step != "agreeing on the message to sign" || !slices.Equal(disagreementErr.Dissenters, []string{dissenter}) {
+					t.Fatalf("party %v: unexpected disagreement: %s", i, disagreementErr)
+				}
+			}
+			if tssMessages.Load() != 0 {
+				t.Fatalf("the signers sent %v tss messages before they agreed", tssMessages.Load())
+			}
+		})
+	}
+}
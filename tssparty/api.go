@@ -1,29 +1,43 @@
 package tssparty
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/bnb-chain/tss-lib/v2/tss"
-	"github.com/swarmlab-dev/go-partybus/partybus"
 )
 
 func ConnectAndGetKeyShare(party KeygenTssParty, partyBusUrl string, sessionId string) (string, error) {
+	return ConnectAndGetKeyShareContext(context.Background(), party, partyBusUrl, sessionId)
+}
+
+func ConnectAndGetKeyShareContext(ctx context.Context, party KeygenTssParty, partyBusUrl string, sessionId string) (string, error) {
+	return ConnectAndGetKeyShareWithTransportContext(ctx, party, NewPartyBusTransport(partyBusUrl), sessionId)
+}
+
+func ConnectAndGetKeyShareWithTransport(party KeygenTssParty, transport Transport, sessionId string) (string, error) {
+	return ConnectAndGetKeyShareWithTransportContext(context.Background(), party, transport, sessionId)
+}
+
+func ConnectAndGetKeyShareWithTransportContext(ctx context.Context, party KeygenTssParty, transport Transport, sessionId string) (string, error) {
 	defer party.Clean()
 
-	err := party.Init()
+	err := party.InitContext(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	_, err = party.PrepareTransport(partyBusUrl, sessionId, party.GetPartyCount())
+	_, err = party.PrepareTransportContext(ctx, transport, sessionId, party.GetPartyCount())
 	if err != nil {
 		return "", err
 	}
 
-	ret, err := party.GetKeyShare()
+	ret, err := party.GetKeyShareContext(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -31,19 +45,97 @@ func ConnectAndGetKeyShare(party KeygenTssParty, partyBusUrl string, sessionId s
 }
 
 func ConnectAndSignMessage(party SigningTssParty, partyBusUrl string, sessionId string, msg string) (string, error) {
+	return ConnectAndSignMessageContext(context.Background(), party, partyBusUrl, sessionId, msg)
+}
+
+func ConnectAndSignMessageContext(ctx context.Context, party SigningTssParty, partyBusUrl string, sessionId string, msg string) (string, error) {
+	return ConnectAndSignMessageWithTransportContext(ctx, party, NewPartyBusTransport(partyBusUrl), sessionId, msg)
+}
+
+func ConnectAndSignMessageWithTransport(party SigningTssParty, transport Transport, sessionId string, msg string) (string, error) {
+	return ConnectAndSignMessageWithTransportContext(context.Background(), party, transport, sessionId, msg)
+}
+
+func ConnectAndSignMessageWithTransportContext(ctx context.Context, party SigningTssParty, transport Transport, sessionId string, msg string) (string, error) {
 	defer party.Clean()
 
-	err := party.Init()
+	err := party.InitContext(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	_, err = party.PrepareTransport(partyBusUrl, sessionId, party.GetThreshold()+1)
+	_, err = party.PrepareTransportContext(ctx, transport, sessionId, party.GetThreshold()+1)
 	if err != nil {
 		return "", err
 	}
 
-	ret, err := party.SignMessage(msg)
+	ret, err := party.SignMessageContext(ctx, msg)
+	if err != nil {
+		return "", err
+	}
+	return ret, nil
+}
+
+func ConnectAndSignMessages(party SigningTssParty, partyBusUrl string, sessionId string, msgs []string) ([]string, error) {
+	return ConnectAndSignMessagesContext(context.Background(), party, partyBusUrl, sessionId, msgs)
+}
+
+func ConnectAndSignMessagesContext(ctx context.Context, party SigningTssParty, partyBusUrl string, sessionId string, msgs []string) ([]string, error) {
+	return ConnectAndSignMessagesWithTransportContext(ctx, party, NewPartyBusTransport(partyBusUrl), sessionId, msgs)
+}
+
+func ConnectAndSignMessagesWithTransport(party SigningTssParty, transport Transport, sessionId string, msgs []string) ([]string, error) {
+	return ConnectAndSignMessagesWithTransportContext(context.Background(), party, transport, sessionId, msgs)
+}
+
+// ConnectAndSignMessagesWithTransportContext signs a batch of messages in a single session,
+// the signatures are returned in the order of the messages
+func ConnectAndSignMessagesWithTransportContext(ctx context.Context, party SigningTssParty, transport Transport, sessionId string, msgs []string) ([]string, error) {
+	defer party.Clean()
+
+	err := party.InitContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = party.PrepareTransportContext(ctx, transport, sessionId, party.GetThreshold()+1)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := party.SignMessagesContext(ctx, msgs)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func ConnectAndReshareKey(party ResharingTssParty, partyBusUrl string, sessionId string) (string, error) {
+	return ConnectAndReshareKeyContext(context.Background(), party, partyBusUrl, sessionId)
+}
+
+func ConnectAndReshareKeyContext(ctx context.Context, party ResharingTssParty, partyBusUrl string, sessionId string) (string, error) {
+	return ConnectAndReshareKeyWithTransportContext(ctx, party, NewPartyBusTransport(partyBusUrl), sessionId)
+}
+
+func ConnectAndReshareKeyWithTransport(party ResharingTssParty, transport Transport, sessionId string) (string, error) {
+	return ConnectAndReshareKeyWithTransportContext(context.Background(), party, transport, sessionId)
+}
+
+func ConnectAndReshareKeyWithTransportContext(ctx context.Context, party ResharingTssParty, transport Transport, sessionId string) (string, error) {
+	defer party.Clean()
+
+	err := party.InitContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = party.PrepareTransportContext(ctx, transport, sessionId, party.GetThreshold()+1+party.GetNewPartyCount())
+	if err != nil {
+		return "", err
+	}
+
+	ret, err := party.ReshareKeyContext(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -56,6 +148,9 @@ func NewTssPartyState(localParty *tss.PartyID, n int, t int) *tssPartyState {
 		thisParty: localParty,
 		n:         n,
 		t:         t,
+		progress:  make(chan struct{}, 1),
+		failures:  make(chan *ProtocolError, 1),
+		stopped:   make(chan struct{}),
 	}
 }
 
@@ -68,19 +163,27 @@ func (party *tssPartyState) GetThreshold() int {
 }
 
 func (party *tssPartyState) Init() error {
+	return party.InitContext(context.Background())
+}
+
+func (party *tssPartyState) InitContext(ctx context.Context) error {
 	return party.stateFunc(IDLE, INITIALIZED, func() error {
 		return nil
 	})
 }
 
-func (party *tssPartyState) PrepareTransport(partyBusUrl string, sessionId string, n int) (string, error) {
-	err := party.ConnectToPartyBus(partyBusUrl, sessionId)
+func (party *tssPartyState) PrepareTransport(transport Transport, sessionId string, n int) (string, error) {
+	return party.PrepareTransportContext(context.Background(), transport, sessionId, n)
+}
+
+func (party *tssPartyState) PrepareTransportContext(ctx context.Context, transport Transport, sessionId string, n int) (string, error) {
+	err := party.ConnectToTransport(transport, sessionId)
 	if err != nil {
 		party.step = ERROR
 		return "", err
 	}
 
-	ret, err := party.WaitForGuestsAndExchangeIDs(n)
+	ret, err := party.WaitForGuestsAndExchangeIDsContext(ctx, n)
 	if err != nil {
 		return "", err
 	}
@@ -89,32 +192,121 @@ func (party *tssPartyState) PrepareTransport(partyBusUrl string, sessionId strin
 }
 
 func (party *tssPartyState) ConnectToPartyBus(partyBusUrl string, sessionId string) error {
+	return party.ConnectToTransport(NewPartyBusTransport(partyBusUrl), sessionId)
+}
+
+func (party *tssPartyState) ConnectToTransport(transport Transport, sessionId string) error {
 	return party.stateFunc(INITIALIZED, CONNECTED_TO_BUS, func() error {
-		party.outBus = make(chan partybus.PeerMessage)
-		in, sig, err := partybus.ConnectToPartyBus(partyBusUrl, sessionId, party.thisParty.Id, party.outBus)
+		// fail before joining rather than once the guests arrived
+		err := party.checkIdentity()
 		if err != nil {
 			return err
 		}
-		party.inBus = in
-		party.sigBus = sig
+		err = transport.Connect(sessionId, party.thisParty.Id)
+		if err != nil {
+			return err
+		}
+		party.transport = transport
+		party.sessionId = sessionId
 		party.aboardBus = true
+		party.inbox = map[peerMessageType]*queue[inboundMessage]{
+			ANNOUNCEMENT_MESSAGE: newQueue[inboundMessage](),
+			TSS_MESSAGE:          newQueue[inboundMessage](),
+			CONFIRMATION_MESSAGE: newQueue[inboundMessage](),
+			INTENT_MESSAGE:       newQueue[inboundMessage](),
+			COMMITMENT_MESSAGE:   newQueue[inboundMessage](),
+			CHAIN_CODE_MESSAGE:   newQueue[inboundMessage](),
+			PROPOSAL_MESSAGE:     newQueue[inboundMessage](),
+		}
+		go party.routeIncomingMessages()
 		return nil
 	})
 }
 
+// routeIncomingMessages sorts the messages coming from the transport by type, as a
+// fast peer may already send tss messages while we are still waiting for announcements
+func (party *tssPartyState) routeIncomingMessages() {
+	defer func() {
+		for _, q := range party.inbox {
+			q.Close()
+		}
+	}()
+
+	for msg := range party.transport.Receive() {
+		var envelope peerMessage
+		if err := json.Unmarshal(msg.Msg, &envelope); err != nil {
+			logger.Errorf("dropping malformed message from peer %s: %s", msg.From, err.Error())
+			continue
+		}
+		q, ok := party.inbox[envelope.Type]
+		if !ok {
+			logger.Errorf("dropping message of unknown type %v from peer %s", envelope.Type, msg.From)
+			continue
+		}
+		q.Push(inboundMessage{From: msg.From, peerMessage: envelope})
+	}
+}
+
+func (party *tssPartyState) receive(msgType peerMessageType) <-chan inboundMessage {
+	return party.inbox[msgType].Out()
+}
+
+func (party *tssPartyState) send(msgType peerMessageType, to []string, isBroadcast bool, payload []byte) error {
+	return party.sendMessage(peerMessage{Type: msgType, IsBroadcast: isBroadcast, Payload: payload}, to)
+}
+
+func (party *tssPartyState) sendMessage(msg peerMessage, to []string) error {
+	msgJson, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if len(to) == 0 {
+		return party.transport.Broadcast(msgJson)
+	}
+	return party.transport.Multicast(to, msgJson)
+}
+
+// sendPrivate encrypts the payload of msg for each recipient and sends it to them one by one
+func (party *tssPartyState) sendPrivate(msg peerMessage, to []string) error {
+	payload := msg.Payload
+	for _, peerId := range to {
+		ciphertext, err := party.encryptFor(peerId, payload)
+		if err != nil {
+			return err
+		}
+		msg.Encrypted = true
+		msg.Payload = ciphertext
+		msgJson, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		err = party.transport.Unicast(peerId, msgJson)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (party *tssPartyState) DisconnectFromBus() error {
 	party.aboardBus = false
-	close(party.outBus)
-	return nil
+
+	// let the last outgoing messages reach the transport before leaving
+	party.outgoing.Wait()
+	return party.transport.Close()
 }
 
 func (party *tssPartyState) WaitForGuestsAndExchangeIDs(n int) (string, error) {
-	err := party.WaitForGuests(n)
+	return party.WaitForGuestsAndExchangeIDsContext(context.Background(), n)
+}
+
+func (party *tssPartyState) WaitForGuestsAndExchangeIDsContext(ctx context.Context, n int) (string, error) {
+	err := party.WaitForGuestsContext(ctx, n)
 	if err != nil {
 		return "", err
 	}
 
-	ret, err := party.ExchangeIds(n)
+	ret, err := party.ExchangeIdsContext(ctx, n)
 	if err != nil {
 		return "", err
 	}
@@ -123,54 +315,200 @@ func (party *tssPartyState) WaitForGuestsAndExchangeIDs(n int) (string, error) {
 }
 
 func (party *tssPartyState) WaitForGuests(n int) error {
+	return party.WaitForGuestsContext(context.Background(), n)
+}
+
+func (party *tssPartyState) WaitForGuestsContext(ctx context.Context, n int) error {
 	return party.stateFunc(CONNECTED_TO_BUS, PEERS_CONNECTED, func() error {
-		logger.Debugf("wait for %v guest before starting the party...", party.n)
+		ctx, cancel := withTimeout(ctx, party.timeouts.GuestWait)
+		defer cancel()
+
+		logger.Debugf("wait for %v guest before starting the party...", n)
 		var guests []string
-		for status := range party.sigBus {
-			guests = status.Peers
-			if len(guests) == n {
-				break
+		for !party.enoughGuests(guests, n) {
+			select {
+			case status, ok := <-party.transport.Presence():
+				if !ok {
+					return fmt.Errorf("channel closed before all guests arrived")
+				}
+				guests = status.Peers
+			case <-ctx.Done():
+				var missing []string
+				if party.roster != nil {
+					missing = party.missingPeers(party.expectedPeers(), toSet(guests))
+				}
+				return contextError(ctx, "waiting for guests", missing)
 			}
 		}
-		if len(guests) != n {
-			return fmt.Errorf("channel closed before all guests arrived")
+		party.guests = guests
+		if party.signers != nil {
+			err := party.selectQuorum(guests, n)
+			if err != nil {
+				return err
+			}
 		}
+
+		// keep draining presence updates so that they do not stall incoming peer messages,
+		// the latest one tells the id exchange about the peers that left
+		party.presence = make(chan []string, 1)
+		go func() {
+			for status := range party.transport.Presence() {
+				select {
+				case <-party.presence:
+				default:
+				}
+				party.presence <- status.Peers
+			}
+		}()
 		logger.Debugf("party got %v guests: [ %s ]", n, strings.Join(guests, ", "))
 		return nil
 	})
 }
 
+// announcement signs the announcement of the local party, started tells late holders
+// that the signers were already selected
+func (party *tssPartyState) announcement(started bool) ([]byte, error) {
+	params := party.ceremonyParams()
+	thisPartyJson, err := json.Marshal(partyAnnouncement{
+		PartyID:       party.thisParty,
+		OldCommittee:  party.oldCommittee,
+		EncryptionKey: party.encryptionKey.PublicKey().Bytes(),
+		Ceremony:      &params,
+		Signers:       party.quorum(),
+		Started:       started,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return party.signAnnouncement(thisPartyJson)
+}
+
+// enoughGuests tells whether the wait for guests is over, signing waits for n holders of the key
+// while the other ceremonies wait for exactly n guests
+func (party *tssPartyState) enoughGuests(guests []string, n int) bool {
+	if party.signers != nil {
+		return party.signers.enoughHolders(guests, n)
+	}
+	return len(guests) == n
+}
+
 func (party *tssPartyState) ExchangeIds(n int) (string, error) {
+	return party.ExchangeIdsContext(context.Background(), n)
+}
+
+func (party *tssPartyState) ExchangeIdsContext(ctx context.Context, n int) (string, error) {
 	return party.stateFunc2(PEERS_CONNECTED, PEERS_KNOWN, func() (string, error) {
+		ctx, cancel := withTimeout(ctx, party.timeouts.IdExchange)
+		defer cancel()
+
 		logger.Debug("exchanging party ids...")
 
 		parties := make([]*tss.PartyID, n)
 		parties[0] = party.thisParty
 
-		thisPartyJson, err := json.Marshal(party.thisParty)
+		party.oldCommitteeIds = make(map[string]bool)
+		if party.oldCommittee {
+			party.oldCommitteeIds[party.thisParty.Id] = true
+		}
+
+		err := party.newEncryptionKey()
 		if err != nil {
 			return "", err
 		}
-		party.outBus <- partybus.NewBroadcastMessage(party.thisParty.Id, thisPartyJson)
+
+		signedJson, err := party.announcement(false)
+		if err != nil {
+			return "", err
+		}
+		err = party.send(ANNOUNCEMENT_MESSAGE, nil, true, signedJson)
+		if err != nil {
+			return "", err
+		}
+		// a holder that stands by announces the signers it selected, so that they fail when they selected others
+		if party.signers != nil && party.signers.standBy {
+			return "", fmt.Errorf("%w, the selected signers are [ %s ]", ErrStandBy, strings.Join(party.signers.quorum, ", "))
+		}
 
 		i := 1
-		for msg := range party.inBus {
-			var peerPartyId tss.PartyID
-			err := json.Unmarshal(msg.Msg, &peerPartyId)
+		announced := make(map[string]bool)
+		var left []string
+		var leftGrace <-chan time.Time
+		for i < n {
+			var msg inboundMessage
+			var ok bool
+			select {
+			case msg, ok = <-party.receive(ANNOUNCEMENT_MESSAGE):
+			case peers := <-party.presence:
+				// the announcement of a peer that just left may still be on its way
+				left = slices.DeleteFunc(party.missingPeers(party.expectedPeers(), announced), func(id string) bool { return slices.Contains(peers, id) })
+				if len(left) > 0 && leftGrace == nil {
+					leftGrace = time.After(leftPeerGrace)
+				}
+				continue
+			case <-leftGrace:
+				left = slices.DeleteFunc(left, func(id string) bool { return announced[id] })
+				if len(left) > 0 {
+					return "", fmt.Errorf("peers left the session before announcing themselves: [ %s ]", strings.Join(left, ", "))
+				}
+				leftGrace = nil
+				continue
+			case <-ctx.Done():
+				return "", contextError(ctx, "exchanging ids", party.missingPeers(party.expectedPeers(), announced))
+			}
+			if !ok {
+				break
+			}
+
+			if !party.inQuorum(msg.From) {
+				err = party.outsiderAnnouncement(msg)
+				if err != nil {
+					return "", err
+				}
+				continue
+			}
+			if announced[msg.From] {
+				return "", fmt.Errorf("peer %s announced itself twice", msg.From)
+			}
+
+			announcementJson, err := party.openAnnouncement(msg.From, msg.Payload)
 			if err != nil {
 				return "", err
 			}
 
-			if msg.From != peerPartyId.Id {
+			var announcement partyAnnouncement
+			err = json.Unmarshal(announcementJson, &announcement)
+			if err != nil {
+				return "", err
+			}
+
+			peerPartyId := announcement.PartyID
+			if peerPartyId == nil || msg.From != peerPartyId.Id {
 				return "", fmt.Errorf("partyId should be the same as message origin")
 			}
+			err = party.checkCeremony(msg.From, announcement.Ceremony)
+			if err != nil {
+				return "", err
+			}
+			err = party.checkSigner(peerPartyId, announcement.Signers)
+			if err != nil {
+				return "", err
+			}
+			announced[msg.From] = true
 
-			parties[i] = &peerPartyId
-			i++
+			err = party.setPeerEncryptionKey(peerPartyId.Id, announcement.EncryptionKey)
+			if err != nil {
+				return "", err
+			}
 
-			if i == n {
-				break
+			if announcement.OldCommittee {
+				party.oldCommitteeIds[peerPartyId.Id] = true
 			}
+			parties[i] = peerPartyId
+			i++
+		}
+		if i != n {
+			missing := party.missingPeers(party.expectedPeers(), announced)
+			return "", fmt.Errorf("channel closed before all peers announced themselves, missing: [ %s ]", strings.Join(missing, ", "))
 		}
 
 		party.sortedParties = tss.SortPartyIDs(parties)
@@ -178,6 +516,9 @@ func (party *tssPartyState) ExchangeIds(n int) (string, error) {
 		for _, id := range party.sortedParties {
 			party.partyIDMap[id.Id] = id
 		}
+		if party.signers != nil {
+			go party.answerLateHolders()
+		}
 
 		ret := strings.Join(MapArrayOfPartyID(party.sortedParties, func(p *tss.PartyID) string { return fmt.Sprintf("%s:%s", p.Id, hex.EncodeToString(p.Key)) }), ",")
 		logger.Debugf("sorted parties: [ %s ]", strings.Join(MapArrayOfPartyID(party.sortedParties, func(p *tss.PartyID) string { return fmt.Sprintf("%s (%s)", p.Id, hex.EncodeToString(p.Key)) }), ", "))
@@ -194,25 +535,127 @@ func (party *tssPartyState) GetParams(useEdwardCurve bool) *tss.Parameters {
 	}
 }
 
+// ProcessOutgoingMessageToTransport sends the messages of the local party, the caller adds it to
+// party.outgoing before starting it so that DisconnectFromBus waits for it
 func (party *tssPartyState) ProcessOutgoingMessageToTransport(outCh <-chan tss.Message) {
-	for msg := range outCh {
-		bytes, _, err := msg.WireBytes()
-		if err != nil {
-			logger.Errorf("error while wiring message to peers: %s", err.Error())
-			return
+	party.processOutgoingMessages(0, outCh)
+}
+
+// processOutgoingMessages sends the messages of one tss party of a batch, tagged with its instance
+func (party *tssPartyState) processOutgoingMessages(instance int, outCh <-chan tss.Message) {
+	defer party.outgoing.Done()
+
+	for {
+		select {
+		case msg, ok := <-outCh:
+			if !ok || !party.sendTssMessage(instance, msg) {
+				return
+			}
+		case <-party.stopped:
+			// flush what the local party already produced
+			for {
+				select {
+				case msg, ok := <-outCh:
+					if !ok || !party.sendTssMessage(instance, msg) {
+						return
+					}
+				default:
+					return
+				}
+			}
 		}
-		to := MapArrayOfPartyID(msg.GetTo(), func(p *tss.PartyID) string { return p.Id })
-		party.outBus <- partybus.NewMulticastMessage(party.thisParty.Id, to, bytes)
 	}
 }
 
+func (party *tssPartyState) sendTssMessage(instance int, msg tss.Message) bool {
+	party.trackRound(msg)
+	bytes, routing, err := msg.WireBytes()
+	if err != nil {
+		party.fail(newProtocolError("wiring message", err))
+		return false
+	}
+	to := MapArrayOfPartyID(msg.GetTo(), func(p *tss.PartyID) string { return p.Id })
+	envelope := peerMessage{Type: TSS_MESSAGE, Instance: instance, IsBroadcast: routing.IsBroadcast, Payload: bytes}
+	if routing.IsBroadcast || len(to) == 0 {
+		err = party.sendMessage(envelope, to)
+	} else {
+		err = party.sendPrivate(envelope, to)
+	}
+	if err != nil {
+		party.fail(newProtocolError("sending message", err))
+		return false
+	}
+	party.notifyProgress()
+	return true
+}
+
 func (party *tssPartyState) ProcessIncomingMessageFromTransport(localParty tss.Party) {
-	for msg := range party.inBus {
-		_, err := localParty.UpdateFromBytes(msg.Msg, party.partyIDMap[msg.From], msg.IsBroadcast())
+	party.processIncomingMessages([]tss.Party{localParty})
+}
+
+// processIncomingMessages hands the tss messages to the parties of a batch by instance, each
+// party processes its messages on its own so that their rounds are computed concurrently
+func (party *tssPartyState) processIncomingMessages(localParties []tss.Party) {
+	inboxes := make([]*queue[inboundMessage], len(localParties))
+	for i, localParty := range localParties {
+		inboxes[i] = newQueue[inboundMessage]()
+		defer inboxes[i].Close()
+		go party.updateParty(localParty, inboxes[i].Out())
+	}
+
+	for {
+		var msg inboundMessage
+		var ok bool
+		select {
+		case msg, ok = <-party.receive(TSS_MESSAGE):
+			if !ok {
+				return
+			}
+		case <-party.stopped:
+			return
+		}
+
+		if msg.Instance < 0 || msg.Instance >= len(inboxes) {
+			party.fail(newProtocolError("receiving message", fmt.Errorf("unknown instance %v from peer %s", msg.Instance, msg.From), msg.From))
+			return
+		}
+		inboxes[msg.Instance].Push(msg)
+	}
+}
+
+// updateParty feeds a tss party with its messages
+func (party *tssPartyState) updateParty(localParty tss.Party, inbox <-chan inboundMessage) {
+	for msg := range inbox {
+		from, ok := party.partyIDMap[msg.From]
+		if !ok {
+			party.fail(newProtocolError("receiving message", fmt.Errorf("unknown peer %s", msg.From), msg.From))
+			return
+		}
+		payload := msg.Payload
+		if !msg.IsBroadcast {
+			if !msg.Encrypted {
+				party.fail(newProtocolError("receiving message", fmt.Errorf("unencrypted point-to-point message from peer %s", msg.From), msg.From))
+				return
+			}
+			plaintext, err := party.decryptFrom(msg.From, msg.Payload)
+			if err != nil {
+				party.fail(newProtocolError("decrypting message", err, msg.From))
+				return
+			}
+			payload = plaintext
+		}
+		parsed, err := tss.ParseWireMessage(payload, from, msg.IsBroadcast)
 		if err != nil {
-			logger.Errorf("error while receiving message from peer %s: %s", msg.From, err.Error())
+			party.fail(newProtocolError("parsing message", err, msg.From))
+			return
+		}
+		party.trackPeerRound(msg.From, parsed)
+		_, errp := localParty.Update(parsed)
+		if errp != nil {
+			party.fail(newProtocolError("processing message", errp))
 			return
 		}
+		party.notifyProgress()
 	}
 }
 
@@ -0,0 +1,204 @@
+package tssparty
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+var (
+	testPreParamsOnce sync.Once
+	testPreParams     []string
+)
+
+// preParamsFor returns the ecdsa preparams of testdata for the i-th party of a ceremony,
+// generating them would take minutes
+func preParamsFor(t *testing.T, i int) string {
+	testPreParamsOnce.Do(func() {
+		data, err := os.ReadFile("testdata/preparams.json")
+		if err != nil {
+			return
+		}
+		var all []json.RawMessage
+		if json.Unmarshal(data, &all) != nil {
+			return
+		}
+		for _, preParams := range all {
+			testPreParams = append(testPreParams, string(preParams))
+		}
+	})
+	if i >= len(testPreParams) {
+		t.Fatalf("testdata holds %v preparams, party %v needs more", len(testPreParams), i)
+	}
+	return testPreParams[i]
+}
+
+// runParties runs one party per index concurrently and returns their outputs and errors
+func runParties(count int, run func(i int) (string, error)) ([]string, []error) {
+	outputs := make([]string, count)
+	errs := make([]error, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			outputs[i], errs[i] = run(i)
+		}(i)
+	}
+	wg.Wait()
+	return outputs, errs
+}
+
+func requireNoErrors(t *testing.T, errs []error) {
+	t.Helper()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("party %v: %s", i, err)
+		}
+	}
+}
+
+// newTestKeygenParty returns the keygen party p<i>, the parties of the tests run without roster
+// unless the test sets one
+func newTestKeygenParty(t *testing.T, curve string, i int, n int, threshold int) (KeygenTssParty, error) {
+	var party KeygenTssParty
+	partyId := fmt.Sprintf("p%d", i)
+	if curve == CurveEd25519 {
+		party = NewEddsaKeygenTssParty(partyId, n, threshold)
+	} else {
+		var err error
+		party, err = NewEcdsaKeygenTssPartyWithPreParams(partyId, preParamsFor(t, i), n, threshold)
+		if err != nil {
+			return nil, err
+		}
+	}
+	party.SetInsecure(true)
+	return party, nil
+}
+
+// testKeygen runs a keygen between n parties named p0 to pn-1
+func testKeygen(t *testing.T, hub *MemoryHub, curve string, n int, threshold int) []string {
+	t.Helper()
+	shares, errs := runParties(n, func(i int) (string, error) {
+		party, err := newTestKeygenParty(t, curve, i, n, threshold)
+		if err != nil {
+			return "", err
+		}
+		return ConnectAndGetKeyShareWithTransport(party, hub.NewTransport(), "keygen")
+	})
+	requireNoErrors(t, errs)
+	return shares
+}
+
+func newTestSigningParty(keyShare string) (SigningTssParty, error) {
+	share, err := ParseKeyShare(keyShare)
+	if err != nil {
+		return nil, err
+	}
+	var party SigningTssParty
+	if share.Curve == CurveEd25519 {
+		party, err = NewEddsaSigningTssParty("", keyShare, 0, 0)
+	} else {
+		party, err = NewEcdsaSigningTssParty("", keyShare, 0, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	party.SetInsecure(true)
+	return party, nil
+}
+
+// testSign signs msg with the given shares, the signatures of the holders that stood by are empty
+func testSign(t *testing.T, hub *MemoryHub, shares []string, sessionId string, msg string) []string {
+	t.Helper()
+	signatures, errs := runParties(len(shares), func(i int) (string, error) {
+		party, err := newTestSigningParty(shares[i])
+		if err != nil {
+			return "", err
+		}
+		signature, err := ConnectAndSignMessageWithTransport(party, hub.NewTransport(), sessionId, msg)
+		if errors.Is(err, ErrStandBy) {
+			return "", nil
+		}
+		return signature, err
+	})
+	requireNoErrors(t, errs)
+	return signatures
+}
+
+// requireValidSignature checks a signature of msg against the public key of a key share
+func requireValidSignature(t *testing.T, keyShare string, msg string, jsonSignature string) {
+	t.Helper()
+	share, err := ParseKeyShare(keyShare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := hex.DecodeString(share.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := JsonToSignature(jsonSignature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	format := SignatureFormatCompact
+	if share.Curve == CurveEd25519 {
+		format = SignatureFormatEd25519
+	}
+	encoded, err := EncodeSignature(jsonSignature, format, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = VerifySignature(publicKey, []byte(msg), encoded, share.Curve, signature.HashMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeygenAndSigning(t *testing.T) {
+	tests := []struct {
+		curve   string
+		n, t    int
+		signers int
+	}{
+		{CurveEd25519, 3, 1, 2},
+		{CurveEd25519, 4, 2, 4},
+		{CurveEd25519, 5, 3, 4},
+		{CurveSecp256k1, 3, 1, 3},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %v-of-%v", test.curve, test.t+1, test.n), func(t *testing.T) {
+			hub := NewMemoryHub()
+			shares := testKeygen(t, hub, test.curve, test.n, test.t)
+
+			publicKey := ""
+			for _, keyShare := range shares {
+				share, err := ParseKeyShare(keyShare)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if publicKey != "" && share.PublicKey != publicKey {
+					t.Fatalf("parties got different public keys %s and %s", publicKey, share.PublicKey)
+				}
+				publicKey = share.PublicKey
+			}
+
+			signatures := testSign(t, hub, shares[:test.signers], "signing", "hello world")
+			signed := 0
+			for i, signature := range signatures {
+				if signature == "" {
+					continue
+				}
+				signed++
+				requireValidSignature(t, shares[i], "hello world", signature)
+			}
+			if signed != test.t+1 {
+				t.Fatalf("expected %v signers but %v signed", test.t+1, signed)
+			}
+		})
+	}
+}
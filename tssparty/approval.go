@@ -0,0 +1,92 @@
+package tssparty
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bnb-chain/tss-lib/v2/crypto"
+)
+
+// ErrDeclined is returned by a signing party whose approval declined to sign
+var ErrDeclined = errors.New("signing declined")
+
+// PeerDeclinedError is returned when some signers declined to sign
+type PeerDeclinedError struct {
+	Signers []string
+}
+
+func (err *PeerDeclinedError) Error() string {
+	return fmt.Sprintf("signers declined to sign: [ %s ]", strings.Join(err.Signers, ", "))
+}
+
+// SigningIntent describes what a signing party is about to sign, once the signers are selected
+type SigningIntent struct {
+	Session   string
+	PartyId   string // of the local party
+	Curve     string
+	HashMode  HashMode
+	Path      string   // BIP32 path of the child key, empty for the key of the share
+	PublicKey string   // hex encoded key the signatures verify with
+	Messages  [][]byte // as given to the party
+	Digests   [][]byte // as hashed by the hash mode, the values signed
+	Signers   []string // party ids of the selected signers, the local one included
+}
+
+// Approval decides whether a signing party signs, an error declines
+type Approval func(ctx context.Context, intent SigningIntent) error
+
+// SetApproval makes the signing party ask approve before the signing round, once the
+// signers are selected. Every signer asks its own approval, the peers of a signer that
+// declines fail with a PeerDeclinedError.
+func (party *tssPartyState) SetApproval(approve Approval) error {
+	if party.signers == nil {
+		return fmt.Errorf("approval only applies to signing")
+	}
+	party.signers.approve = approve
+	return nil
+}
+
+// approveSigning asks the approval of the signing party, a party that declines tells its peers
+// with an empty intent so that they do not wait for it
+func (party *tssPartyState) approveSigning(ctx context.Context, intent SigningIntent) error {
+	if party.signers == nil || party.signers.approve == nil {
+		return nil
+	}
+	err := party.signers.approve(ctx, intent)
+	if err == nil {
+		return nil
+	}
+	logger.Warnf("declining to sign: %s", err.Error())
+	sendErr := party.sendSigned(INTENT_MESSAGE, intentSignatureContext, nil, false, nil)
+	if sendErr != nil {
+		logger.Errorf("cannot tell the peers that signing is declined: %s", sendErr.Error())
+	}
+	return fmt.Errorf("%w: %w", ErrDeclined, err)
+}
+
+// newSigningIntent describes the signing of messages with publicKey by the selected signers
+func (party *tssPartyState) newSigningIntent(curve string, hashMode HashMode, path []uint32, publicKey *crypto.ECPoint, messages [][]byte) (SigningIntent, error) {
+	intent := SigningIntent{
+		Session:   party.sessionId,
+		PartyId:   party.thisParty.Id,
+		Curve:     curve,
+		HashMode:  hashMode,
+		PublicKey: hex.EncodeToString(encodePublicKey(curve, publicKey)),
+		Messages:  messages,
+		Signers:   party.quorum(),
+	}
+	if len(path) > 0 {
+		intent.Path = formatDerivationPath(path)
+	}
+	for _, message := range messages {
+		digest, err := HashMessage(message, hashMode, curve)
+		if err != nil {
+			return intent, err
+		}
+		intent.Digests = append(intent.Digests, digest)
+	}
+	return intent, nil
+}
@@ -0,0 +1,73 @@
+package tssparty
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+// runBatch runs count tss parties over the session of the local party, newParty creates
+// the party of instance i. Their messages are tagged with their instance, and their results
+// are returned in order once all of them are done.
+func runBatch[T any](ctx context.Context, party *tssPartyState, task string, count int, newParty func(i int, outCh chan<- tss.Message, endCh chan<- T) tss.Party) ([]T, error) {
+	defer party.stopProcessingMessages()
+
+	localParties := make([]tss.Party, count)
+	outChs := make([]chan tss.Message, count)
+	endChs := make([]<-chan T, count)
+	for i := range localParties {
+		outChs[i] = make(chan tss.Message)
+		endCh := make(chan T, 1)
+		endChs[i] = endCh
+		localParties[i] = newParty(i, outChs[i], endCh)
+	}
+
+	// start
+	for i := range localParties {
+		party.outgoing.Add(1)
+		go party.processOutgoingMessages(i, outChs[i])
+	}
+	go party.processIncomingMessages(localParties)
+	for _, localParty := range localParties {
+		// the first round of each party is computed concurrently too
+		go func(localParty tss.Party) {
+			errp := localParty.Start()
+			if errp != nil {
+				party.fail(newProtocolError("starting party", errp))
+			}
+		}(localParty)
+	}
+
+	return waitForResults(ctx, party, task, localParties, endChs)
+}
+
+// hashMessages turns each message of a batch into the value signed by tss-lib
+func hashMessages(messages []string, mode HashMode, curve string) ([][]byte, []*big.Int, error) {
+	if len(messages) == 0 {
+		return nil, nil, fmt.Errorf("no message to sign")
+	}
+
+	raw := make([][]byte, len(messages))
+	digests := make([]*big.Int, len(messages))
+	for i, message := range messages {
+		raw[i] = []byte(message)
+		digest, err := HashMessage(raw[i], mode, curve)
+		if err == nil && curve == CurveEd25519 && len(digest) > 0 && digest[0] == 0 {
+			// tss-lib v2 drops the leading zeros of the message when hashing it
+			if mode == HashModeEddsa {
+				return nil, nil, fmt.Errorf("message %v starts with a zero byte, which tss-lib v2 cannot sign with eddsa", i)
+			}
+			return nil, nil, fmt.Errorf("the %s digest of message %v starts with a zero byte, which tss-lib v2 cannot sign with eddsa, try another hash mode", mode, i)
+		}
+		if err != nil && len(messages) > 1 {
+			return nil, nil, fmt.Errorf("message %v: %w", i, err)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		digests[i] = new(big.Int).SetBytes(digest)
+	}
+	return raw, digests, nil
+}
@@ -0,0 +1,90 @@
+package tssparty
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testSignBatch signs msgs with each of the shares, which must all be signers
+func testSignBatch(t *testing.T, hub *MemoryHub, shares []string, sessionId string, msgs func(i int) []string) ([][]string, []error) {
+	t.Helper()
+	signatures := make([][]string, len(shares))
+	_, errs := runParties(len(shares), func(i int) (string, error) {
+		party, err := newTestSigningParty(shares[i])
+		if err != nil {
+			return "", err
+		}
+		signatures[i], err = ConnectAndSignMessagesWithTransport(party, hub.NewTransport(), sessionId, msgs(i))
+		return "", err
+	})
+	return signatures, errs
+}
+
+func TestBatchSigning(t *testing.T) {
+	tests := []struct {
+		curve string
+		count int
+	}{
+		{CurveEd25519, 20},
+		{CurveSecp256k1, 3},
+	}
+	for _, test := range tests {
+		t.Run(test.curve, func(t *testing.T) {
+			hub := NewMemoryHub()
+			shares := testKeygen(t, hub, test.curve, 3, 1)
+			var msgs []string
+			for i := 0; i < test.count; i++ {
+				msgs = append(msgs, fmt.Sprintf("message %d", i))
+			}
+
+			signatures, errs := testSignBatch(t, hub, shares[1:], "batch", func(i int) []string { return msgs })
+			requireNoErrors(t, errs)
+			for _, signed := range signatures {
+				if len(signed) != len(msgs) {
+					t.Fatalf("expected %v signatures but got %v", len(msgs), len(signed))
+				}
+				for i, signature := range signed {
+					requireValidSignature(t, shares[0], msgs[i], signature)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchMismatch(t *testing.T) {
+	hub := NewMemoryHub()
+	shares := testKeygen(t, hub, CurveEd25519, 3, 1)
+
+	_, errs := testSignBatch(t, hub, shares[:2], "batch", func(i int) []string {
+		return []string{"pay bob 10", fmt.Sprintf("pay carol %d", 10+i)}
+	})
+	for i, err := range errs {
+		if err == nil {
+			t.Fatalf("party %v signed a batch its peer did not agree on", i)
+		}
+	}
+}
+
+func TestHashMessages(t *testing.T) {
+	_, _, err := hashMessages(nil, HashModeSha256, CurveSecp256k1)
+	if err == nil {
+		t.Error("accepted an empty batch")
+	}
+	// tss-lib v2 would drop the leading zero of an eddsa message
+	_, _, err = hashMessages([]string{"01ff", "00ff"}, HashModeDigestHex, CurveEd25519)
+	if err == nil || err.Error() != "the digest-hex digest of message 1 starts with a zero byte, which tss-lib v2 cannot sign with eddsa, try another hash mode" {
+		t.Errorf("accepted an eddsa message starting with a zero byte: %v", err)
+	}
+	_, _, err = hashMessages([]string{"\x00ok"}, HashModeEddsa, CurveEd25519)
+	if err == nil || !strings.Contains(err.Error(), "message 0 starts with a zero byte") {
+		t.Errorf("accepted an eddsa message starting with a zero byte: %v", err)
+	}
+	raw, digests, err := hashMessages([]string{"a", "b"}, HashModeSha256, CurveSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != 2 || string(raw[1]) != "b" || len(digests) != 2 || digests[0].Cmp(digests[1]) == 0 {
+		t.Errorf("unexpected batch %q %v", raw, digests)
+	}
+}
@@ -0,0 +1,57 @@
+package tssparty
+
+import "fmt"
+
+// Version of the library, announced to the peers of a ceremony who must run the same one
+const Version = "1.0.0"
+
+const (
+	ProtocolKeygen    = "keygen"
+	ProtocolSigning   = "signing"
+	ProtocolResharing = "resharing"
+)
+
+// ceremonyParams are announced by every party, so that peers started with other parameters are
+// rejected before the ceremony begins
+type ceremonyParams struct {
+	Protocol string `json:"protocol"`
+	Curve    string `json:"curve"`
+	N        int    `json:"n"`
+	T        int    `json:"t"`
+	NewN     int    `json:"newN,omitempty"`
+	NewT     int    `json:"newT,omitempty"`
+	Version  string `json:"version"`
+}
+
+func (params ceremonyParams) String() string {
+	str := fmt.Sprintf("%s ceremony on %s with n=%v t=%v", params.Protocol, params.Curve, params.N, params.T)
+	if params.Protocol == ProtocolResharing {
+		str += fmt.Sprintf(" new-n=%v new-t=%v", params.NewN, params.NewT)
+	}
+	return fmt.Sprintf("%s (version %s)", str, params.Version)
+}
+
+func (party *tssPartyState) withCeremony(protocol string, curve string) *tssPartyState {
+	party.ceremony.Protocol = protocol
+	party.ceremony.Curve = curve
+	return party
+}
+
+func (party *tssPartyState) ceremonyParams() ceremonyParams {
+	params := party.ceremony
+	params.N = party.n
+	params.T = party.t
+	params.Version = Version
+	return params
+}
+
+// checkCeremony rejects a peer whose announced parameters differ from the local ones
+func (party *tssPartyState) checkCeremony(from string, params *ceremonyParams) error {
+	if params == nil {
+		return fmt.Errorf("peer %s did not announce the parameters of its ceremony", from)
+	}
+	if local := party.ceremonyParams(); *params != local {
+		return fmt.Errorf("peer %s joined a %s but the local party expects a %s", from, params, local)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package tssparty
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCeremonyMismatch runs a third party started with other parameters, a party started with another n
+// waits for another number of guests so it is checked by TestCheckCeremony
+func TestCeremonyMismatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		curve           string // of the third party
+		n, threshold    int
+		expected, local string
+	}{
+		{"threshold", CurveEd25519, 3, 2, "n=3 t=2", "n=3 t=1"},
+		{"curve", CurveSecp256k1, 3, 1, "keygen ceremony on " + CurveSecp256k1, "keygen ceremony on " + CurveEd25519},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := NewMemoryHub()
+			var tssMessages atomic.Int32
+			_, errs := runParties(3, func(i int) (string, error) {
+				curve, n, threshold := CurveEd25519, 3, 1
+				if i == 2 {
+					curve, n, threshold = test.curve, test.n, test.threshold
+				}
+				party, err := newTestKeygenParty(t, curve, i, n, threshold)
+				if err != nil {
+					return "", err
+				}
+				party.SetTimeouts(Timeouts{GuestWait: 10 * time.Second, IdExchange: 10 * time.Second})
+				transport := &tamperingTransport{hub.NewTransport(), tamperTssMessages(func(to []string, msg *peerMessage) bool {
+					tssMessages.Add(1)
+					return true
+				})}
+				return ConnectAndGetKeyShareWithTransport(party, transport, "keygen")
+			})
+
+			for i, err := range errs[:2] {
+				if err == nil || !strings.Contains(err.Error(), "peer p2 joined a "+ProtocolKeygen) ||
+					!strings.Contains(err.Error(), test.expected) || !strings.Contains(err.Error(), "expects a "+ProtocolKeygen) {
+					t.Fatalf("party %v: expected p2 to be rejected, got: %v", i, err)
+				}
+				if !strings.Contains(err.Error(), test.local) {
+					t.Fatalf("party %v: the error does not tell the local parameters: %v", i, err)
+				}
+			}
+			if errs[2] == nil {
+				t.Fatal("p2 ran a ceremony its peers did not agree on")
+			}
+			if tssMessages.Load() != 0 {
+				t.Fatalf("the parties sent %v tss messages before the parameters were checked", tssMessages.Load())
+			}
+		})
+	}
+}
+
+func TestCheckCeremony(t *testing.T) {
+	party := NewTssPartyState(NewPartyID("p0", nil), 3, 1).withCeremony(ProtocolKeygen, CurveEd25519)
+	local := party.ceremonyParams()
+	if err := party.checkCeremony("p1", &local); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(params *ceremonyParams)
+	}{
+		{"n", func(params *ceremonyParams) { params.N = 4 }},
+		{"threshold", func(params *ceremonyParams) { params.T = 2 }},
+		{"curve", func(params *ceremonyParams) { params.Curve = CurveSecp256k1 }},
+		{"protocol", func(params *ceremonyParams) { params.Protocol = ProtocolResharing }},
+		{"version", func(params *ceremonyParams) { params.Version = "0.9.0" }},
+	}
+	for _, test := range tests {
+		params := local
+		test.change(&params)
+		err := party.checkCeremony("p1", &params)
+		if err == nil || !strings.Contains(err.Error(), "peer p1 joined a "+params.String()+" but the local party expects a "+local.String()) {
+			t.Errorf("%s: expected p1 to be rejected, got: %v", test.name, err)
+		}
+	}
+	if err := party.checkCeremony("p1", nil); err == nil {
+		t.Error("accepted a peer that did not announce its parameters")
+	}
+}
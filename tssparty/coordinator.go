@@ -0,0 +1,462 @@
+package tssparty
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Instead of agreeing out of band on a session id and the parameters of a ceremony, the parties
+// may meet in a lobby, a session of the bus where one of them proposes the ceremony. Each participant
+// accepts or rejects the proposal, and the ceremony starts once a quorum accepted, its session id
+// being the id of the proposal.
+
+const proposalSignatureContext = "tssparty proposal v1"
+
+const (
+	proposeKind = "propose"
+	acceptKind  = "accept"
+	rejectKind  = "reject"
+	startKind   = "start"
+	cancelKind  = "cancel"
+)
+
+// Proposal is a ceremony proposed in a lobby
+type Proposal struct {
+	Id       string `json:"id"` // session id of the ceremony
+	Proposer string `json:"proposer"`
+	Protocol string `json:"protocol"` // keygen, signing or resharing
+	Curve    string `json:"curve"`
+	N        int    `json:"n"`
+	T        int    `json:"t"`
+	NewN     int    `json:"newN,omitempty"`
+	NewT     int    `json:"newT,omitempty"`
+
+	PublicKey string `json:"publicKey,omitempty"` // hex encoded key signed with or reshared
+
+	// signing
+	HashMode HashMode `json:"hashMode,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Messages [][]byte `json:"messages,omitempty"` // base64 in JSON, messages need not be text
+	Digests  [][]byte `json:"digests,omitempty"`  // of the messages, as hashed by the hash mode
+
+	Participants []string `json:"participants"`       // party ids asked to take part
+	Quorum       int      `json:"quorum"`             // acceptances required to start, the proposer counts when it participates
+	Accepted     []string `json:"accepted,omitempty"` // participants that accepted, once started
+}
+
+// ProposalRejectedError is returned to the proposer when too many participants rejected its proposal
+type ProposalRejectedError struct {
+	Rejections map[string]string // reason given by each participant that rejected
+}
+
+func (err *ProposalRejectedError) Error() string {
+	var rejections []string
+	for id, reason := range err.Rejections {
+		rejections = append(rejections, fmt.Sprintf("%s: %s", id, reason))
+	}
+	sort.Strings(rejections)
+	return fmt.Sprintf("proposal rejected by [ %s ]", strings.Join(rejections, ", "))
+}
+
+// Decision accepts a proposal made to the local party, an error rejects it
+type Decision func(ctx context.Context, proposal Proposal) error
+
+// coordinationMessage is the payload exchanged in a lobby
+type coordinationMessage struct {
+	Kind     string    `json:"kind"`
+	Id       string    `json:"id"`
+	Proposal *Proposal `json:"proposal,omitempty"` // proposed
+	Accepted []string  `json:"accepted,omitempty"` // started
+	Reason   string    `json:"reason,omitempty"`   // rejected
+}
+
+// Coordinator proposes ceremonies in a lobby or attends the ones proposed to it. With a roster,
+// only the proposals and answers signed by the identity key of a member of the roster are trusted.
+type Coordinator struct {
+	party    *tssPartyState
+	proposed map[string]bool // ids of the proposals received, not to answer a replay
+
+	lock    sync.Mutex
+	present []string      // peers in the lobby
+	joined  chan struct{} // signalled when the peers in the lobby change
+}
+
+func NewCoordinator(localId string) *Coordinator {
+	party := NewTssPartyState(NewPartyID(localId, nil), 0, 0)
+	party.step = INITIALIZED
+	return &Coordinator{party: party, proposed: make(map[string]bool), joined: make(chan struct{}, 1)}
+}
+
+func (coordinator *Coordinator) SetIdentity(identityKey ed25519.PrivateKey) error {
+	return coordinator.party.SetIdentity(identityKey)
+}
+
+func (coordinator *Coordinator) SetRoster(roster []RosterEntry) error {
+	return coordinator.party.SetRoster(roster)
+}
+
+func (coordinator *Coordinator) SetInsecure(insecure bool) {
+	coordinator.party.SetInsecure(insecure)
+}
+
+// Connect joins the lobby
+func (coordinator *Coordinator) Connect(transport Transport, lobby string) error {
+	party := coordinator.party
+	err := party.ConnectToTransport(transport, lobby)
+	if err != nil {
+		return err
+	}
+
+	// presence updates are kept for the proposer, and must be drained not to stall incoming messages
+	go func() {
+		defer close(coordinator.joined)
+		for status := range transport.Presence() {
+			coordinator.lock.Lock()
+			coordinator.present = status.Peers
+			coordinator.lock.Unlock()
+			select {
+			case coordinator.joined <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return nil
+}
+
+// Close leaves the lobby
+func (coordinator *Coordinator) Close() error {
+	return coordinator.party.DisconnectFromBus()
+}
+
+func (coordinator *Coordinator) Propose(proposal Proposal) (*Proposal, error) {
+	return coordinator.ProposeContext(context.Background(), proposal)
+}
+
+// ProposeContext sends a proposal to its participants, including the ones joining the lobby later, and
+// waits for a quorum of them to accept it. The proposal is then started and returned with its id and the
+// participants that accepted it, the ceremony can begin. The proposal is cancelled when ctx is done.
+func (coordinator *Coordinator) ProposeContext(ctx context.Context, proposal Proposal) (*Proposal, error) {
+	party := coordinator.party
+	localId := party.thisParty.Id
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	proposal.Id = hex.EncodeToString(id)
+	proposal.Proposer = localId
+	proposal.Accepted = nil
+	if proposal.Quorum == 0 {
+		proposal.Quorum = len(proposal.Participants)
+		if proposal.Protocol == ProtocolSigning {
+			proposal.Quorum = proposal.T + 1
+		}
+	}
+	if proposal.Protocol == ProtocolSigning && proposal.HashMode == "" {
+		proposal.HashMode = DefaultHashMode(proposal.Curve)
+	}
+	if proposal.Protocol == ProtocolSigning {
+		digests, err := proposal.digests()
+		if err != nil {
+			return nil, err
+		}
+		proposal.Digests = digests
+	}
+	err := proposal.check()
+	if err != nil {
+		return nil, err
+	}
+
+	var accepted []string
+	if slices.Contains(proposal.Participants, localId) {
+		accepted = append(accepted, localId)
+	}
+	rejections := make(map[string]string)
+	cancel := func() {
+		err := coordinator.send(nil, coordinationMessage{Kind: cancelKind, Id: proposal.Id})
+		if err != nil {
+			logger.Errorf("cannot cancel proposal %s: %s", proposal.Id, err.Error())
+		}
+	}
+
+	logger.Infof("proposing %s", proposal)
+	sent := map[string]bool{localId: true}
+	sendToPresent := func() error {
+		coordinator.lock.Lock()
+		var to []string
+		for _, peer := range coordinator.present {
+			if slices.Contains(proposal.Participants, peer) && !sent[peer] {
+				sent[peer] = true
+				to = append(to, peer)
+			}
+		}
+		coordinator.lock.Unlock()
+		if len(to) == 0 {
+			return nil
+		}
+		return coordinator.send(to, coordinationMessage{Kind: proposeKind, Id: proposal.Id, Proposal: &proposal})
+	}
+	err = sendToPresent()
+	if err != nil {
+		return nil, err
+	}
+
+	for len(accepted) < proposal.Quorum {
+		if len(proposal.Participants)-len(rejections) < proposal.Quorum {
+			cancel()
+			return nil, &ProposalRejectedError{Rejections: rejections}
+		}
+
+		select {
+		case _, ok := <-coordinator.joined:
+			if !ok {
+				return nil, fmt.Errorf("channel closed while waiting for the participants to accept the proposal")
+			}
+			err = sendToPresent()
+			if err != nil {
+				return nil, err
+			}
+
+		case msg, ok := <-party.receive(PROPOSAL_MESSAGE):
+			if !ok {
+				return nil, fmt.Errorf("channel closed while waiting for the participants to accept the proposal")
+			}
+			answer, err := coordinator.open(msg)
+			if err != nil || answer.Id != proposal.Id {
+				continue
+			}
+			answered := slices.Contains(accepted, msg.From) || rejections[msg.From] != ""
+			if !slices.Contains(proposal.Participants, msg.From) || answered {
+				continue
+			}
+			switch answer.Kind {
+			case acceptKind:
+				logger.Infof("participant %s accepted the proposal", msg.From)
+				accepted = append(accepted, msg.From)
+			case rejectKind:
+				logger.Warnf("participant %s rejected the proposal: %s", msg.From, answer.Reason)
+				rejections[msg.From] = answer.Reason
+				if answer.Reason == "" {
+					rejections[msg.From] = "no reason given"
+				}
+			}
+
+		case <-ctx.Done():
+			cancel()
+			var missing []string
+			for _, participant := range proposal.Participants {
+				if !slices.Contains(accepted, participant) && rejections[participant] == "" {
+					missing = append(missing, participant)
+				}
+			}
+			return nil, contextError(ctx, "waiting for the participants to accept the proposal", missing)
+		}
+	}
+
+	sort.Strings(accepted)
+	proposal.Accepted = accepted
+	err = coordinator.send(nil, coordinationMessage{Kind: startKind, Id: proposal.Id, Accepted: accepted})
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("proposal %s accepted by [ %s ]", proposal.Id, strings.Join(accepted, ", "))
+	return &proposal, nil
+}
+
+func (coordinator *Coordinator) Attend(decide Decision) (*Proposal, error) {
+	return coordinator.AttendContext(context.Background(), decide)
+}
+
+// AttendContext waits for the proposals made to the local party and answers them with decide. It
+// returns the first proposal it accepted that is started with the local party, the ceremony can begin.
+func (coordinator *Coordinator) AttendContext(ctx context.Context, decide Decision) (*Proposal, error) {
+	party := coordinator.party
+	localId := party.thisParty.Id
+	accepted := make(map[string]*Proposal)
+	for {
+		var msg inboundMessage
+		var ok bool
+		select {
+		case msg, ok = <-party.receive(PROPOSAL_MESSAGE):
+		case <-ctx.Done():
+			return nil, contextError(ctx, "waiting for a proposal", nil)
+		}
+		if !ok {
+			return nil, fmt.Errorf("channel closed while waiting for a proposal")
+		}
+		content, err := coordinator.open(msg)
+		if err != nil {
+			continue
+		}
+
+		switch content.Kind {
+		case proposeKind:
+			proposal := content.Proposal
+			if proposal == nil || proposal.Id != content.Id || proposal.Proposer != msg.From || coordinator.proposed[proposal.Id] {
+				logger.Errorf("ignoring an invalid proposal from %s", msg.From)
+				continue
+			}
+			if !slices.Contains(proposal.Participants, localId) {
+				continue
+			}
+			coordinator.proposed[proposal.Id] = true
+
+			err = coordinator.decide(ctx, *proposal, decide)
+			answer := coordinationMessage{Kind: acceptKind, Id: proposal.Id}
+			if err != nil {
+				logger.Warnf("rejecting proposal %s of %s: %s", proposal.Id, msg.From, err.Error())
+				answer = coordinationMessage{Kind: rejectKind, Id: proposal.Id, Reason: err.Error()}
+			} else {
+				logger.Infof("accepting proposal %s of %s", proposal.Id, msg.From)
+				accepted[proposal.Id] = proposal
+			}
+			err = coordinator.send([]string{msg.From}, answer)
+			if err != nil {
+				return nil, err
+			}
+
+		case startKind:
+			proposal, ok := accepted[content.Id]
+			if !ok || proposal.Proposer != msg.From {
+				continue
+			}
+			delete(accepted, content.Id)
+			if !slices.Contains(content.Accepted, localId) {
+				logger.Infof("proposal %s started without the local party", content.Id)
+				continue
+			}
+			proposal.Accepted = content.Accepted
+			return proposal, nil
+
+		case cancelKind:
+			if proposal, ok := accepted[content.Id]; ok && proposal.Proposer == msg.From {
+				logger.Infof("proposal %s was cancelled", content.Id)
+				delete(accepted, content.Id)
+			}
+		}
+	}
+}
+
+// decide checks a proposal before asking decide
+func (coordinator *Coordinator) decide(ctx context.Context, proposal Proposal, decide Decision) error {
+	err := proposal.check()
+	if err != nil {
+		return err
+	}
+	if proposal.Protocol == ProtocolSigning {
+		digests, err := proposal.digests()
+		if err != nil {
+			return err
+		}
+		if !slices.EqualFunc(digests, proposal.Digests, bytes.Equal) {
+			return fmt.Errorf("digests do not match the messages")
+		}
+	}
+	return decide(ctx, proposal)
+}
+
+func (coordinator *Coordinator) send(to []string, content coordinationMessage) error {
+	contentJson, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	return coordinator.party.sendSigned(PROPOSAL_MESSAGE, proposalSignatureContext, to, false, contentJson)
+}
+
+func (coordinator *Coordinator) open(msg inboundMessage) (*coordinationMessage, error) {
+	contentJson, err := coordinator.party.openPeerMessage(proposalSignatureContext, "proposal message", msg.From, msg.Payload)
+	if err != nil {
+		logger.Errorf("ignoring a proposal message of %s: %s", msg.From, err.Error())
+		return nil, err
+	}
+	var content coordinationMessage
+	err = json.Unmarshal(contentJson, &content)
+	if err != nil {
+		logger.Errorf("ignoring a malformed proposal message of %s", msg.From)
+		return nil, err
+	}
+	return &content, nil
+}
+
+// check validates the parameters of a proposal
+func (proposal *Proposal) check() error {
+	if proposal.Curve != CurveSecp256k1 && proposal.Curve != CurveEd25519 {
+		return fmt.Errorf("unsupported curve %s", proposal.Curve)
+	}
+	if proposal.T < 1 || proposal.T >= proposal.N {
+		return fmt.Errorf("threshold (t) must be between 1 and party count (n) - 1")
+	}
+	for i, id := range proposal.Participants {
+		if id == "" || slices.Contains(proposal.Participants[:i], id) {
+			return fmt.Errorf("participants must be distinct party ids")
+		}
+	}
+	if proposal.Quorum < 1 || proposal.Quorum > len(proposal.Participants) {
+		return fmt.Errorf("a quorum of %v cannot be reached with %v participants", proposal.Quorum, len(proposal.Participants))
+	}
+
+	switch proposal.Protocol {
+	case ProtocolKeygen:
+		// the keygen waits for every party
+		if len(proposal.Participants) != proposal.N || proposal.Quorum != proposal.N {
+			return fmt.Errorf("a keygen with n=%v needs %v participants that all accept", proposal.N, proposal.N)
+		}
+	case ProtocolResharing:
+		if proposal.PublicKey == "" {
+			return fmt.Errorf("a resharing proposal needs the public key of the key reshared")
+		}
+		if proposal.NewT < 1 || proposal.NewT >= proposal.NewN {
+			return fmt.Errorf("new threshold must be between 1 and new party count - 1")
+		}
+		// the resharing waits for t+1 members of the old committee and every member of the new one
+		if parties := proposal.T + 1 + proposal.NewN; len(proposal.Participants) != parties || proposal.Quorum != parties {
+			return fmt.Errorf("a resharing with t=%v and new-n=%v needs %v participants that all accept", proposal.T, proposal.NewN, parties)
+		}
+	case ProtocolSigning:
+		if proposal.PublicKey == "" || len(proposal.Messages) == 0 {
+			return fmt.Errorf("a signing proposal needs a public key and messages")
+		}
+		if proposal.Quorum <= proposal.T {
+			return fmt.Errorf("a quorum of %v cannot sign with threshold %v", proposal.Quorum, proposal.T)
+		}
+		if _, err := ParseDerivationPath(proposal.Path); err != nil {
+			return err
+		}
+		return checkHashMode(proposal.HashMode, proposal.Curve)
+	default:
+		return fmt.Errorf("unknown protocol %s", proposal.Protocol)
+	}
+	return nil
+}
+
+// digests hashes the messages of a signing proposal
+func (proposal *Proposal) digests() ([][]byte, error) {
+	digests := make([][]byte, len(proposal.Messages))
+	for i, message := range proposal.Messages {
+		digest, err := HashMessage(message, proposal.HashMode, proposal.Curve)
+		if err != nil {
+			return nil, err
+		}
+		digests[i] = digest
+	}
+	return digests, nil
+}
+
+func (proposal Proposal) String() string {
+	str := fmt.Sprintf("%s on %s with n=%v t=%v", proposal.Protocol, proposal.Curve, proposal.N, proposal.T)
+	switch proposal.Protocol {
+	case ProtocolResharing:
+		str += fmt.Sprintf(" new-n=%v new-t=%v of key %s", proposal.NewN, proposal.NewT, proposal.PublicKey)
+	case ProtocolSigning:
+		str += fmt.Sprintf(" of %v messages with key %s", len(proposal.Messages), proposal.PublicKey)
+	}
+	return fmt.Sprintf("%s to [ %s ], %v must accept", str, strings.Join(proposal.Participants, ", "), proposal.Quorum)
+}
@@ -0,0 +1,183 @@
+package tssparty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestProposalCheck(t *testing.T) {
+	keygen := Proposal{Protocol: ProtocolKeygen, Curve: CurveEd25519, N: 3, T: 1, Participants: []string{"a", "b", "c"}, Quorum: 3}
+	resharing := Proposal{Protocol: ProtocolResharing, Curve: CurveEd25519, N: 3, T: 1, NewN: 3, NewT: 1, PublicKey: "00", Participants: []string{"a", "b", "d", "e", "f"}, Quorum: 5}
+	signing := Proposal{Protocol: ProtocolSigning, Curve: CurveEd25519, N: 3, T: 1, PublicKey: "00", HashMode: HashModeEddsa, Messages: [][]byte{[]byte("hello")}, Participants: []string{"a", "b", "c"}, Quorum: 2}
+
+	tests := []struct {
+		name   string
+		change func(proposal *Proposal)
+		valid  bool
+	}{
+		{"keygen", func(proposal *Proposal) { *proposal = keygen }, true},
+		{"keygen without every party", func(proposal *Proposal) { *proposal = keygen; proposal.Quorum = 2 }, false},
+		{"keygen with fewer participants than n", func(proposal *Proposal) {
+			*proposal = keygen
+			proposal.Participants = []string{"a", "b"}
+			proposal.Quorum = 2
+		}, false},
+		{"resharing", func(proposal *Proposal) { *proposal = resharing }, true},
+		{"resharing without every party", func(proposal *Proposal) { *proposal = resharing; proposal.Quorum = 4 }, false},
+		{"resharing with the whole old committee", func(proposal *Proposal) {
+			*proposal = resharing
+			proposal.Participants = []string{"a", "b", "c", "d", "e", "f"}
+			proposal.Quorum = 6
+		}, false},
+		{"signing", func(proposal *Proposal) { *proposal = signing }, true},
+		{"signing below the threshold", func(proposal *Proposal) { *proposal = signing; proposal.Quorum = 1 }, false},
+		{"signing without messages", func(proposal *Proposal) { *proposal = signing; proposal.Messages = nil }, false},
+		{"duplicate participants", func(proposal *Proposal) { *proposal = signing; proposal.Participants = []string{"a", "a", "c"} }, false},
+	}
+	for _, test := range tests {
+		var proposal Proposal
+		test.change(&proposal)
+		err := proposal.check()
+		if test.valid && err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected the proposal to be rejected", test.name)
+		}
+	}
+}
+
+// lobbyParties connects one coordinator per id to the lobby
+func lobbyParties(t *testing.T, hub *MemoryHub, ids []string) map[string]*Coordinator {
+	t.Helper()
+	coordinators := make(map[string]*Coordinator)
+	for _, id := range ids {
+		coordinator := NewCoordinator(id)
+		coordinator.SetInsecure(true)
+		err := coordinator.Connect(hub.NewTransport(), "lobby")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { coordinator.Close() })
+		coordinators[id] = coordinator
+	}
+	return coordinators
+}
+
+func TestLobbySigning(t *testing.T) {
+	hub := NewMemoryHub()
+	shares := testKeygen(t, hub, CurveEd25519, 3, 1)
+	byId := make(map[string]string)
+	var ids []string
+	for _, keyShare := range shares {
+		share, err := ParseKeyShare(keyShare)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byId[share.PartyId] = keyShare
+		ids = append(ids, share.PartyId)
+	}
+	share, _ := ParseKeyShare(shares[0])
+	proposer, accepting, rejecting := ids[0], ids[1], ids[2]
+	coordinators := lobbyParties(t, hub, ids)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	proposals := make([]*Proposal, len(ids))
+	_, errs := runParties(3, func(i int) (string, error) {
+		id := ids[i]
+		var proposal *Proposal
+		var err error
+		switch id {
+		case proposer:
+			proposal, err = coordinators[id].ProposeContext(ctx, Proposal{
+				Protocol:     ProtocolSigning,
+				Curve:        share.Curve,
+				N:            share.N,
+				T:            share.T,
+				PublicKey:    share.PublicKey,
+				Messages:     [][]byte{[]byte("pay bob 10")},
+				Participants: ids,
+			})
+		case accepting:
+			proposal, err = coordinators[id].AttendContext(ctx, func(ctx context.Context, proposal Proposal) error { return nil })
+		case rejecting:
+			attendCtx, stop := context.WithTimeout(ctx, 2*time.Second)
+			defer stop()
+			_, err = coordinators[id].AttendContext(attendCtx, func(ctx context.Context, proposal Proposal) error {
+				return fmt.Errorf("not today")
+			})
+			var timeout *TimeoutError
+			if errors.As(err, &timeout) {
+				err = nil
+			}
+			return "", err
+		}
+		if err != nil {
+			return "", err
+		}
+		proposals[i] = proposal
+		return "", nil
+	})
+	requireNoErrors(t, errs)
+
+	started, attended := proposals[0], proposals[1]
+	if started.Id != attended.Id {
+		t.Fatalf("the parties started different proposals %s and %s", started.Id, attended.Id)
+	}
+	if !slices.Equal(started.Accepted, attended.Accepted) || slices.Contains(started.Accepted, rejecting) || len(started.Accepted) != 2 {
+		t.Fatalf("unexpected signers %v and %v", started.Accepted, attended.Accepted)
+	}
+
+	signatures, errs := runParties(2, func(i int) (string, error) {
+		party, err := newTestSigningParty(byId[started.Accepted[i]])
+		if err != nil {
+			return "", err
+		}
+		err = party.SetSignerPreference(started.Accepted)
+		if err != nil {
+			return "", err
+		}
+		return ConnectAndSignMessageWithTransport(party, hub.NewTransport(), started.Id, string(started.Messages[0]))
+	})
+	requireNoErrors(t, errs)
+	requireValidSignature(t, shares[0], "pay bob 10", signatures[0])
+}
+
+func TestProposalRejected(t *testing.T) {
+	hub := NewMemoryHub()
+	coordinators := lobbyParties(t, hub, []string{"a", "b", "c"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	attendCtx, stopAttending := context.WithCancel(ctx)
+	for _, id := range []string{"b", "c"} {
+		id := id
+		go coordinators[id].AttendContext(attendCtx, func(ctx context.Context, proposal Proposal) error {
+			if id == "c" {
+				return fmt.Errorf("not today")
+			}
+			return nil
+		})
+	}
+	defer stopAttending()
+
+	_, err := coordinators["a"].ProposeContext(ctx, Proposal{
+		Protocol:     ProtocolKeygen,
+		Curve:        CurveEd25519,
+		N:            3,
+		T:            1,
+		Participants: []string{"a", "b", "c"},
+	})
+	var rejected *ProposalRejectedError
+	if !errors.As(err, &rejected) {
+		t.Fatalf("expected a ProposalRejectedError, got: %v", err)
+	}
+	if rejected.Rejections["c"] != "not today" || len(rejected.Rejections) != 1 {
+		t.Fatalf("unexpected rejections %v", rejected.Rejections)
+	}
+}
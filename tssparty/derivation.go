@@ -0,0 +1,254 @@
+package tssparty
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bnb-chain/tss-lib/v2/crypto/ckd"
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+const chainCodeSize = 32
+
+const (
+	chainCodeCommitmentContext = "tssparty chain code commitment v1"
+	chainCodeContext           = "tssparty chain code v1"
+	chainCodeHandOverContext   = "tssparty chain code handover v1"
+)
+
+// xpubVersion prefixes the mainnet extended public keys as defined by BIP32
+var xpubVersion = []byte{0x04, 0x88, 0xb2, 0x1e}
+
+// ParseDerivationPath reads a BIP32 path such as m/0/1. A threshold key has no private key to
+// derive hardened children from, only non-hardened indexes are accepted.
+func ParseDerivationPath(path string) ([]uint32, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "m"), "/")
+	if path == "" {
+		return nil, nil
+	}
+
+	var indexes []uint32
+	for _, element := range strings.Split(path, "/") {
+		if strings.HasSuffix(element, "'") || strings.HasSuffix(element, "h") || strings.HasSuffix(element, "H") {
+			return nil, fmt.Errorf("hardened index %s cannot be derived from a threshold key", element)
+		}
+		index, err := strconv.ParseUint(element, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path index %s", element)
+		}
+		if index >= ckd.HardenedKeyStart {
+			return nil, fmt.Errorf("index %s is a hardened one, it cannot be derived from a threshold key", element)
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+func formatDerivationPath(indexes []uint32) string {
+	path := "m"
+	for _, index := range indexes {
+		path += fmt.Sprintf("/%d", index)
+	}
+	return path
+}
+
+// ExtendedPublicKey is a secp256k1 public key along with its BIP32 chain code
+type ExtendedPublicKey struct {
+	key *ckd.ExtendedKey
+}
+
+// KeyShareExtendedPublicKey returns the master extended public key of a key share generated with a chain code
+func KeyShareExtendedPublicKey(jsonKeyShare string) (*ExtendedPublicKey, error) {
+	share, err := ParseKeyShare(jsonKeyShare)
+	if err != nil {
+		return nil, err
+	}
+	chainCode := share.chainCodeBytes()
+	if chainCode == nil {
+		return nil, fmt.Errorf("key share has no chain code, it cannot derive child keys")
+	}
+	encoded, err := hex.DecodeString(share.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := ParsePublicKey(share.Curve, encoded)
+	if err != nil {
+		return nil, err
+	}
+	return newExtendedPublicKey(pub.x, pub.y, chainCode), nil
+}
+
+// ParseExtendedPublicKey reads a base58 xpub, to derive the child public keys of a watch-only wallet
+func ParseExtendedPublicKey(xpub string) (*ExtendedPublicKey, error) {
+	key, err := ckd.NewExtendedKeyFromString(strings.TrimSpace(xpub), tss.S256())
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse extended public key: %w", err)
+	}
+	return &ExtendedPublicKey{key: key}, nil
+}
+
+func newExtendedPublicKey(x *big.Int, y *big.Int, chainCode []byte) *ExtendedPublicKey {
+	return &ExtendedPublicKey{key: &ckd.ExtendedKey{
+		PublicKey: ecdsa.PublicKey{Curve: tss.S256(), X: x, Y: y},
+		ChainCode: chainCode,
+		ParentFP:  []byte{0, 0, 0, 0},
+		Version:   xpubVersion,
+	}}
+}
+
+// Derive returns the extended public key of the child at a non-hardened path
+func (xpub *ExtendedPublicKey) Derive(path string) (*ExtendedPublicKey, error) {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	_, child, err := xpub.derive(indexes)
+	return child, err
+}
+
+// derive also returns the delta to add to the shares of the key to sign for the child
+func (xpub *ExtendedPublicKey) derive(indexes []uint32) (*big.Int, *ExtendedPublicKey, error) {
+	if len(indexes) == 0 {
+		return big.NewInt(0), xpub, nil
+	}
+	curve := tss.S256()
+	delta, child, err := ckd.DeriveChildKeyFromHierarchy(indexes, xpub.key, curve.Params().N, curve)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot derive %s: %w", formatDerivationPath(indexes), err)
+	}
+	return delta, &ExtendedPublicKey{key: child}, nil
+}
+
+// PublicKey returns the public key without its chain code
+func (xpub *ExtendedPublicKey) PublicKey() (*PublicKey, error) {
+	key := make([]byte, 33)
+	key[0] = 2 + byte(xpub.key.Y.Bit(0))
+	xpub.key.X.FillBytes(key[1:])
+	return ParsePublicKey(CurveSecp256k1, key)
+}
+
+// String returns the base58 xpub serialization of BIP32
+func (xpub *ExtendedPublicKey) String() string {
+	return xpub.key.String()
+}
+
+// chainCodeCommitment binds a contribution to the chain code to the party that drew it
+func chainCodeCommitment(partyId string, contribution []byte) []byte {
+	commitment := sha256.Sum256(append([]byte(partyId+"\x00"), contribution...))
+	return commitment[:]
+}
+
+// generateChainCode draws the chain code of a new key with the peers. Each party commits to a random
+// contribution before revealing it, so that no party can choose the chain code, and the contributions
+// are only sent encrypted as the chain code links the child keys to the group key.
+func (party *tssPartyState) generateChainCode(ctx context.Context) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, party.timeouts.Round)
+	defer cancel()
+
+	contribution := make([]byte, chainCodeSize)
+	if _, err := rand.Read(contribution); err != nil {
+		return nil, err
+	}
+	fail := func(operation string, err error, culprits []string) error {
+		protocolErr := newProtocolError(operation, err, culprits...)
+		protocolErr.Task = "keygen"
+		return protocolErr
+	}
+	peers := MapArrayOfPartyID(party.sortedParties, func(p *tss.PartyID) string { return p.Id })
+	others := slices.DeleteFunc(slices.Clone(peers), func(id string) bool { return id == party.thisParty.Id })
+
+	err := party.sendSigned(COMMITMENT_MESSAGE, chainCodeCommitmentContext, nil, false, chainCodeCommitment(party.thisParty.Id, contribution))
+	if err != nil {
+		return nil, err
+	}
+	commitments, culprits, err := party.collect(ctx, COMMITMENT_MESSAGE, chainCodeCommitmentContext, "committing to the chain code", peers)
+	if err != nil {
+		return nil, err
+	}
+	if len(culprits) > 0 {
+		return nil, fail("committing to the chain code", fmt.Errorf("invalid commitment"), culprits)
+	}
+
+	err = party.sendSigned(CHAIN_CODE_MESSAGE, chainCodeContext, others, true, contribution)
+	if err != nil {
+		return nil, err
+	}
+	contributions, culprits, err := party.collect(ctx, CHAIN_CODE_MESSAGE, chainCodeContext, "drawing the chain code", peers)
+	if err != nil {
+		return nil, err
+	}
+	contributions[party.thisParty.Id] = contribution
+	for _, id := range others {
+		if _, ok := contributions[id]; ok && !bytes.Equal(chainCodeCommitment(id, contributions[id]), commitments[id]) {
+			culprits = append(culprits, id)
+		}
+	}
+	if len(culprits) > 0 {
+		sort.Strings(culprits)
+		return nil, fail("drawing the chain code", fmt.Errorf("contribution does not match its commitment"), culprits)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(chainCodeContext))
+	for _, id := range peers {
+		hash.Write(contributions[id])
+	}
+	return hash.Sum(nil), nil
+}
+
+// handOverChainCode sends the chain code held by the old committee to the new one, which checks
+// that the members of the old committee gave the same. A key without chain code hands over nil.
+func (party *resharingTssPartyState) handOverChainCode(ctx context.Context, chainCode []byte) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, party.timeouts.Round)
+	defer cancel()
+
+	oldIds := MapArrayOfPartyID(party.oldCommitteeParties(), func(p *tss.PartyID) string { return p.Id })
+	newIds := MapArrayOfPartyID(party.newCommitteeParties(), func(p *tss.PartyID) string { return p.Id })
+	if party.oldCommittee {
+		return chainCode, party.sendSigned(CHAIN_CODE_MESSAGE, chainCodeHandOverContext, newIds, true, chainCode)
+	}
+
+	step := "handing over the chain code"
+	values, dissenters, err := party.collect(ctx, CHAIN_CODE_MESSAGE, chainCodeHandOverContext, step, oldIds)
+	if err != nil {
+		return nil, err
+	}
+
+	// the chain code given by most of the old committee is kept, the others are named
+	votes := make(map[string]int)
+	for _, value := range values {
+		votes[string(value)]++
+	}
+	var kept string
+	for value, count := range votes {
+		if count > votes[kept] || (count == votes[kept] && value < kept) {
+			kept = value
+		}
+	}
+	for id, value := range values {
+		if string(value) != kept {
+			dissenters = append(dissenters, id)
+		}
+	}
+	if len(dissenters) > 0 {
+		sort.Strings(dissenters)
+		return nil, &DisagreementError{Step: step, Dissenters: dissenters}
+	}
+	if kept == "" {
+		return nil, nil
+	}
+	if len(kept) != chainCodeSize {
+		return nil, fmt.Errorf("old committee handed over an invalid chain code")
+	}
+	return []byte(kept), nil
+}
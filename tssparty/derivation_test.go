@@ -0,0 +1,147 @@
+package tssparty
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseDerivationPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		want  []uint32
+		valid bool
+	}{
+		{"", nil, true},
+		{"m", nil, true},
+		{"m/0/1", []uint32{0, 1}, true},
+		{"0/2147483647", []uint32{0, 2147483647}, true},
+		{"m/0'", nil, false},
+		{"m/0h", nil, false},
+		{"m/2147483648", nil, false},
+		{"m/-1", nil, false},
+		{"m//1", nil, false},
+		{"m/x", nil, false},
+	}
+	for _, test := range tests {
+		indexes, err := ParseDerivationPath(test.path)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.path)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(indexes, test.want) {
+			t.Errorf("%s: got %v: %v", test.path, indexes, err)
+		}
+	}
+}
+
+func TestDeriveExtendedPublicKey(t *testing.T) {
+	// the master key of the second test vector of BIP32 and its child m/0
+	xpub, err := ParseExtendedPublicKey("xpub661MyMwAqRbcFW31YEwpkMuc5THy2PSt5bDMsktWQcFF8syAmRUapSCGu8ED9W6oDMSgv6Zz8idoc4a6mr8BDzTJY47LJhkJ8UB7WEGuduB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := xpub.Derive("m/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if child.String() != "xpub69H7F5d8KSRgmmdJg2KhpAK8SR3DjMwAdkxj3ZuxV27CprR9LgpeyGmXUbC6wb7ERfvrnKZjXoUmmDznezpbZb7ap6r1D3tgFxHmwMkQTPH" {
+		t.Fatalf("unexpected child %s", child)
+	}
+	_, err = xpub.Derive("m/0'")
+	if err == nil {
+		t.Fatal("derived a hardened child from an extended public key")
+	}
+}
+
+func TestDerivedSigning(t *testing.T) {
+	hub := NewMemoryHub()
+	shares := testKeygen(t, hub, CurveSecp256k1, 3, 1)
+
+	// every party contributed to the same chain code
+	chainCode := ""
+	for _, keyShare := range shares {
+		share, err := ParseKeyShare(keyShare)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(share.ChainCode) != 2*chainCodeSize || (chainCode != "" && share.ChainCode != chainCode) {
+			t.Fatalf("unexpected chain codes %q and %q", chainCode, share.ChainCode)
+		}
+		chainCode = share.ChainCode
+	}
+
+	xpub, err := KeyShareExtendedPublicKey(shares[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := xpub.Derive("m/0/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	childKey, err := child.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := KeySharePublicKey(shares[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signatures, errs := runParties(2, func(i int) (string, error) {
+		party, err := newTestSigningParty(shares[i+1])
+		if err != nil {
+			return "", err
+		}
+		err = party.SetDerivationPath("m/0/7")
+		if err != nil {
+			return "", err
+		}
+		return ConnectAndSignMessageWithTransport(party, hub.NewTransport(), "signing", "hello world")
+	})
+	requireNoErrors(t, errs)
+
+	signature, err := JsonToSignature(signatures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature.Path != "m/0/7" {
+		t.Fatalf("the signature tells path %q", signature.Path)
+	}
+	encoded, err := EncodeSignature(signatures[0], SignatureFormatCompact, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = VerifySignature(childKey.Bytes(), []byte("hello world"), encoded, CurveSecp256k1, HashModeSha256)
+	if err != nil {
+		t.Fatalf("the signature does not verify with the child key: %s", err)
+	}
+	err = VerifySignature(master.Bytes(), []byte("hello world"), encoded, CurveSecp256k1, HashModeSha256)
+	if err == nil {
+		t.Fatal("the signature verifies with the master key")
+	}
+
+	// signers deriving different keys disagree before any tss message
+	_, errs = runParties(2, func(i int) (string, error) {
+		party, err := newTestSigningParty(shares[i+1])
+		if err != nil {
+			return "", err
+		}
+		party.SetTimeouts(Timeouts{Round: 10 * time.Second})
+		err = party.SetDerivationPath(fmt.Sprintf("m/0/%d", i))
+		if err != nil {
+			return "", err
+		}
+		return ConnectAndSignMessageWithTransport(party, hub.NewTransport(), "mismatch", "hello world")
+	})
+	for i, err := range errs {
+		var disagreementErr *DisagreementError
+		if !errors.As(err, &disagreementErr) || disagreementErr.Step != "agreeing on the message to sign" {
+			t.Fatalf("party %v: expected a disagreement on the signing intent, got: %v", i, err)
+		}
+	}
+}
@@ -1,8 +1,8 @@
 package tssparty
 
 import (
+	"context"
 	"encoding/json"
-	"time"
 
 	"github.com/bnb-chain/tss-lib/v2/ecdsa/keygen"
 	"github.com/bnb-chain/tss-lib/v2/tss"
@@ -10,7 +10,7 @@ import (
 
 func NewEcdsaKeygenTssParty(localID string, n int, t int) KeygenTssParty {
 	return &EcdsaKeygenTssPartyState{
-		tssPartyState: NewTssPartyState(NewPartyID(localID, nil), n, t),
+		tssPartyState: NewTssPartyState(NewPartyID(localID, nil), n, t).withCeremony(ProtocolKeygen, CurveSecp256k1),
 	}
 }
 
@@ -20,42 +20,76 @@ func NewEcdsaKeygenTssPartyWithKey(localID string, localKeyBase16 string, n int,
 		return nil, err
 	}
 	return &EcdsaKeygenTssPartyState{
-		tssPartyState: NewTssPartyState(partyId, n, t),
+		tssPartyState: NewTssPartyState(partyId, n, t).withCeremony(ProtocolKeygen, CurveSecp256k1),
+	}, nil
+}
+
+// NewEcdsaKeygenTssPartyWithPreParams creates a party that uses pre-parameters generated ahead
+// of time by GeneratePreParams instead of computing them when initialized
+func NewEcdsaKeygenTssPartyWithPreParams(localID string, jsonPreParams string, n int, t int) (KeygenTssParty, error) {
+	preParams, err := JsonToEcdsaPreParams(jsonPreParams)
+	if err != nil {
+		return nil, err
+	}
+	return &EcdsaKeygenTssPartyState{
+		tssPartyState: NewTssPartyState(NewPartyID(localID, nil), n, t).withCeremony(ProtocolKeygen, CurveSecp256k1),
+		preParams:     preParams,
 	}, nil
 }
 
 func (party *EcdsaKeygenTssPartyState) Init() error {
+	return party.InitContext(context.Background())
+}
+
+func (party *EcdsaKeygenTssPartyState) InitContext(ctx context.Context) error {
 	return party.stateFunc(IDLE, INITIALIZED, func() error {
-		logger.Debug("computing preparams...")
-		party.preParams, _ = keygen.GeneratePreParams(1 * time.Minute)
+		if party.preParams != nil {
+			return nil
+		}
+		preParams, err := generatePreParams(ctx, party.timeouts.PreParams)
+		if err != nil {
+			return err
+		}
+		party.preParams = preParams
 		return nil
 	})
 }
 
 func (party *EcdsaKeygenTssPartyState) GetKeyShare() (string, error) {
+	return party.GetKeyShareContext(context.Background())
+}
+
+func (party *EcdsaKeygenTssPartyState) GetKeyShareContext(ctx context.Context) (string, error) {
 	return party.stateFunc2(PEERS_KNOWN, TSS_DONE, func() (string, error) {
 		outCh := make(chan tss.Message)
-		endCh := make(chan *keygen.LocalPartySaveData)
-		defer close(outCh)
-		defer close(endCh)
-		tssParams := party.GetParams(true)
+		endCh := make(chan *keygen.LocalPartySaveData, 1)
+		defer party.stopProcessingMessages()
+		tssParams := party.GetParams(false)
 		ecdsaKeygenParty := keygen.NewLocalParty(tssParams, outCh, endCh, *party.preParams)
 
 		// start
+		party.outgoing.Add(1)
 		go party.ProcessOutgoingMessageToTransport(outCh)
 		go party.ProcessIncomingMessageFromTransport(ecdsaKeygenParty)
 		errp := ecdsaKeygenParty.Start()
 		if errp != nil {
-			return "", errp
+			return "", newProtocolError("starting party", errp)
 		}
 
 		// return generated key share
-		ret := <-endCh
-		jsonRet, err := json.Marshal(ret)
+		ret, err := waitForResult(ctx, party.tssPartyState, "keygen", ecdsaKeygenParty, endCh)
+		if err != nil {
+			return "", err
+		}
+		chainCode, err := party.generateChainCode(ctx)
+		if err != nil {
+			return "", err
+		}
+		err = party.confirmGroupKey(ctx, "keygen", CurveSecp256k1, party.n, party.t, party.sortedParties, ret.ECDSAPub, chainCode)
 		if err != nil {
 			return "", err
 		}
-		return string(jsonRet), nil
+		return party.newKeyShare(CurveSecp256k1, party.n, party.t, party.sortedParties, ret.ECDSAPub, ret, chainCode)
 	})
 }
 
@@ -0,0 +1,137 @@
+package tssparty
+
+import (
+	"context"
+
+	"github.com/bnb-chain/tss-lib/v2/ecdsa/keygen"
+	"github.com/bnb-chain/tss-lib/v2/ecdsa/resharing"
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+// NewEcdsaResharingTssParty creates a party of the old committee when jsonKeyShare
+// is given, or a party of the new committee when jsonKeyShare is empty
+func NewEcdsaResharingTssParty(localID string, jsonKeyShare string, n int, t int, newN int, newT int) (ResharingTssParty, error) {
+	if jsonKeyShare == "" {
+		return &EcdsaResharingTssPartyState{
+			resharingTssPartyState: NewResharingTssPartyState(NewPartyID(localID, nil), false, n, t, newN, newT).withCurve(CurveSecp256k1),
+		}, nil
+	}
+
+	share, err := ParseKeyShare(jsonKeyShare)
+	if err != nil {
+		return nil, err
+	}
+	localID, n, t, err = share.resolve(CurveSecp256k1, localID, n, t)
+	if err != nil {
+		return nil, err
+	}
+	key, err := JsonToEcdsaKey(string(share.Share))
+	if err != nil {
+		return nil, err
+	}
+	err = share.checkShare(key.ShareID, key.Ks, key.ECDSAPub)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EcdsaResharingTssPartyState{
+		resharingTssPartyState: NewResharingTssPartyState(NewPartyID(localID, key.ShareID), true, n, t, newN, newT).withCurve(CurveSecp256k1),
+		keyShare:               key,
+		envelope:               share,
+	}, nil
+}
+
+// NewEcdsaResharingTssPartyWithPreParams creates a party of the new committee that uses pre-parameters
+// generated ahead of time by GeneratePreParams instead of computing them when initialized
+func NewEcdsaResharingTssPartyWithPreParams(localID string, jsonPreParams string, n int, t int, newN int, newT int) (ResharingTssParty, error) {
+	preParams, err := JsonToEcdsaPreParams(jsonPreParams)
+	if err != nil {
+		return nil, err
+	}
+	return &EcdsaResharingTssPartyState{
+		resharingTssPartyState: NewResharingTssPartyState(NewPartyID(localID, nil), false, n, t, newN, newT).withCurve(CurveSecp256k1),
+		preParams:              preParams,
+	}, nil
+}
+
+func (party *EcdsaResharingTssPartyState) Init() error {
+	return party.InitContext(context.Background())
+}
+
+func (party *EcdsaResharingTssPartyState) InitContext(ctx context.Context) error {
+	return party.stateFunc(IDLE, INITIALIZED, func() error {
+		if party.oldCommittee || party.preParams != nil {
+			return nil
+		}
+		preParams, err := generatePreParams(ctx, party.timeouts.PreParams)
+		if err != nil {
+			return err
+		}
+		party.preParams = preParams
+		return nil
+	})
+}
+
+func (party *EcdsaResharingTssPartyState) ReshareKey() (string, error) {
+	return party.ReshareKeyContext(context.Background())
+}
+
+func (party *EcdsaResharingTssPartyState) ReshareKeyContext(ctx context.Context) (string, error) {
+	return party.stateFunc2(PEERS_KNOWN, TSS_DONE, func() (string, error) {
+		tssParams, err := party.GetResharingParams(tss.S256())
+		if err != nil {
+			return "", err
+		}
+
+		// old committee hands over its share, new committee starts from scratch
+		var key keygen.LocalPartySaveData
+		if party.oldCommittee {
+			key = *party.keyShare
+		} else {
+			key = keygen.NewLocalPartySaveData(party.newN)
+			key.LocalPreParams = *party.preParams
+		}
+
+		outCh := make(chan tss.Message)
+		endCh := make(chan *keygen.LocalPartySaveData, 1)
+		defer party.stopProcessingMessages()
+		ecdsaResharingParty := resharing.NewLocalParty(tssParams, key, outCh, endCh)
+
+		// start
+		party.outgoing.Add(1)
+		go party.ProcessOutgoingMessageToTransport(outCh)
+		go party.ProcessIncomingMessageFromTransport(ecdsaResharingParty)
+		errp := ecdsaResharingParty.Start()
+		if errp != nil {
+			return "", newProtocolError("starting party", errp)
+		}
+
+		// wait for the end of the resharing
+		ret, err := waitForResult(ctx, party.tssPartyState, "resharing", ecdsaResharingParty, endCh)
+		if err != nil {
+			return "", err
+		}
+
+		// the old committee confirms the key it held
+		publicKey := ret.ECDSAPub
+		var chainCode []byte
+		if party.oldCommittee {
+			publicKey = party.keyShare.ECDSAPub
+			chainCode = party.envelope.chainCodeBytes()
+		}
+		chainCode, err = party.handOverChainCode(ctx, chainCode)
+		if err != nil {
+			return "", err
+		}
+		err = party.confirmGroupKey(ctx, "resharing", CurveSecp256k1, party.newN, party.newT, party.newCommitteeParties(), publicKey, chainCode)
+		if err != nil {
+			return "", err
+		}
+
+		// old committee members do not receive a new share
+		if ret.Xi == nil {
+			return "", nil
+		}
+		return party.newKeyShare(CurveSecp256k1, party.newN, party.newT, party.newCommitteeParties(), ret.ECDSAPub, ret, chainCode)
+	})
+}
@@ -1,56 +1,151 @@
 package tssparty
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"math/big"
+	"slices"
 
 	"github.com/bnb-chain/tss-lib/v2/common"
+	"github.com/bnb-chain/tss-lib/v2/ecdsa/keygen"
 	"github.com/bnb-chain/tss-lib/v2/ecdsa/signing"
 	"github.com/bnb-chain/tss-lib/v2/tss"
 )
 
 func NewEcdsaSigningTssParty(localID string, jsonKeyShare string, n int, t int) (SigningTssParty, error) {
-	key, err := JsonToEcdsaKey(jsonKeyShare)
+	share, err := ParseKeyShare(jsonKeyShare)
+	if err != nil {
+		return nil, err
+	}
+	localID, n, t, err = share.resolve(CurveSecp256k1, localID, n, t)
+	if err != nil {
+		return nil, err
+	}
+	key, err := JsonToEcdsaKey(string(share.Share))
+	if err != nil {
+		return nil, err
+	}
+	err = share.checkShare(key.ShareID, key.Ks, key.ECDSAPub)
 	if err != nil {
 		return nil, err
 	}
 
 	return &EcdsaSigningTssPartyState{
-		tssPartyState: NewTssPartyState(NewPartyID(localID, key.ShareID), n, t),
+		tssPartyState: NewTssPartyState(NewPartyID(localID, key.ShareID), n, t).withCeremony(ProtocolSigning, CurveSecp256k1).withSigners(share.Roster, key.Ks),
 		keyShare:      key,
+		envelope:      share,
+		hashMode:      DefaultHashMode(CurveSecp256k1),
 	}, nil
 }
 
 func (party *EcdsaSigningTssPartyState) SignMessage(msgToSign string) (string, error) {
-	return party.stateFunc2(PEERS_KNOWN, TSS_DONE, func() (string, error) {
-		// turn msg into a bigint
-		msg := new(big.Int)
-		if ret := msg.SetBytes([]byte(msgToSign)); ret == nil {
-			return "", fmt.Errorf("cannot convert msg into a big int")
+	return party.SignMessageContext(context.Background(), msgToSign)
+}
+
+func (party *EcdsaSigningTssPartyState) SignMessageContext(ctx context.Context, msgToSign string) (string, error) {
+	signatures, err := party.SignMessagesContext(ctx, []string{msgToSign})
+	if err != nil {
+		return "", err
+	}
+	return signatures[0], nil
+}
+
+func (party *EcdsaSigningTssPartyState) SignMessages(msgsToSign []string) ([]string, error) {
+	return party.SignMessagesContext(context.Background(), msgsToSign)
+}
+
+// SignMessagesContext signs a batch of messages over the current session, one tss party per message
+func (party *EcdsaSigningTssPartyState) SignMessagesContext(ctx context.Context, msgsToSign []string) ([]string, error) {
+	var signatures []string
+	err := party.stateFunc(PEERS_KNOWN, TSS_DONE, func() error {
+		messages, digests, err := hashMessages(msgsToSign, party.hashMode, CurveSecp256k1)
+		if err != nil {
+			return err
+		}
+
+		key, delta, err := party.derivedKey()
+		if err != nil {
+			return err
 		}
 
-		outCh := make(chan tss.Message)
-		endCh := make(chan *common.SignatureData)
-		defer close(outCh)
-		defer close(endCh)
-		tssParams := party.GetParams(true)
-		eddsaSigningParty := signing.NewLocalParty(msg, tssParams, *party.keyShare, outCh, endCh)
-
-		// start
-		go party.ProcessOutgoingMessageToTransport(outCh)
-		go party.ProcessIncomingMessageFromTransport(eddsaSigningParty)
-		errp := eddsaSigningParty.Start()
-		if errp != nil {
-			return "", errp
+		intent, err := party.newSigningIntent(CurveSecp256k1, party.hashMode, party.path, key.ECDSAPub, messages)
+		if err != nil {
+			return err
+		}
+		err = party.approveSigning(ctx, intent)
+		if err != nil {
+			return err
 		}
 
-		// return signed message
-		ret := <-endCh
-		jsonRet, err := json.Marshal(ret)
+		err = party.confirmSigningIntent(ctx, CurveSecp256k1, party.hashMode, messages, key.ECDSAPub)
 		if err != nil {
-			return "", err
+			return err
 		}
-		return string(jsonRet), nil
+
+		rets, err := runBatch(ctx, party.tssPartyState, "signing", len(digests), func(i int, outCh chan<- tss.Message, endCh chan<- *common.SignatureData) tss.Party {
+			return signing.NewLocalPartyWithKDD(digests[i], party.GetParams(false), key, delta, outCh, endCh)
+		})
+		if err != nil {
+			return err
+		}
+
+		signatures = make([]string, len(rets))
+		for i, ret := range rets {
+			signatures[i], err = newSignature(CurveSecp256k1, party.hashMode, party.path, ret)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return signatures, nil
+}
+
+func (party *EcdsaSigningTssPartyState) SetHashMode(mode HashMode) error {
+	err := checkHashMode(mode, CurveSecp256k1)
+	if err != nil {
+		return err
+	}
+	party.hashMode = mode
+	return nil
+}
+
+// SetDerivationPath signs with the child key at a non-hardened BIP32 path of the key share, such as m/0/1
+func (party *EcdsaSigningTssPartyState) SetDerivationPath(path string) error {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return err
+	}
+	if len(indexes) > 0 && party.envelope.chainCodeBytes() == nil {
+		return fmt.Errorf("key share has no chain code, it cannot derive child keys")
+	}
+	party.path = indexes
+	return nil
+}
+
+// derivedKey returns the key share adjusted to the child key of the derivation path, along with the
+// delta tss-lib adds to the share of each signer, nil without derivation path
+func (party *EcdsaSigningTssPartyState) derivedKey() (keygen.LocalPartySaveData, *big.Int, error) {
+	key := *party.keyShare
+	if len(party.path) == 0 {
+		return key, nil, nil
+	}
+
+	xpub := newExtendedPublicKey(key.ECDSAPub.X(), key.ECDSAPub.Y(), party.envelope.chainCodeBytes())
+	delta, child, err := xpub.derive(party.path)
+	if err != nil {
+		return key, nil, err
+	}
+	// the public shares are adjusted in place, they must not be shared with the key share
+	keys := []keygen.LocalPartySaveData{key}
+	keys[0].BigXj = slices.Clone(key.BigXj)
+	err = signing.UpdatePublicKeyAndAdjustBigXj(delta, keys, &child.key.PublicKey, tss.S256())
+	if err != nil {
+		return key, nil, err
+	}
+	logger.Infof("signing with the child key at %s", formatDerivationPath(party.path))
+	return keys[0], delta, nil
 }
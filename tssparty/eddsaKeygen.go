@@ -1,6 +1,7 @@
 package tssparty
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/bnb-chain/tss-lib/v2/eddsa/keygen"
@@ -9,7 +10,7 @@ import (
 
 func NewEddsaKeygenTssParty(localID string, n int, t int) KeygenTssParty {
 	return &EddsaKeygenTssPartyState{
-		tssPartyState: NewTssPartyState(NewPartyID(localID, nil), n, t),
+		tssPartyState: NewTssPartyState(NewPartyID(localID, nil), n, t).withCeremony(ProtocolKeygen, CurveEd25519),
 	}
 }
 
@@ -19,35 +20,42 @@ func NewEddsaKeygenTssPartyWithKey(localID string, localKeyBase16 string, n int,
 		return nil, err
 	}
 	return &EddsaKeygenTssPartyState{
-		tssPartyState: NewTssPartyState(partyId, n, t),
+		tssPartyState: NewTssPartyState(partyId, n, t).withCeremony(ProtocolKeygen, CurveEd25519),
 	}, nil
 }
 
 func (party *EddsaKeygenTssPartyState) GetKeyShare() (string, error) {
+	return party.GetKeyShareContext(context.Background())
+}
+
+func (party *EddsaKeygenTssPartyState) GetKeyShareContext(ctx context.Context) (string, error) {
 	return party.stateFunc2(PEERS_KNOWN, TSS_DONE, func() (string, error) {
 		// init keygen party
 		outCh := make(chan tss.Message)
-		endCh := make(chan *keygen.LocalPartySaveData)
-		defer close(outCh)
-		defer close(endCh)
+		endCh := make(chan *keygen.LocalPartySaveData, 1)
+		defer party.stopProcessingMessages()
 		tssParams := party.GetParams(true)
 		eddsaKeygenParty := keygen.NewLocalParty(tssParams, outCh, endCh)
 
 		// start
+		party.outgoing.Add(1)
 		go party.ProcessOutgoingMessageToTransport(outCh)
 		go party.ProcessIncomingMessageFromTransport(eddsaKeygenParty)
 		errp := eddsaKeygenParty.Start()
 		if errp != nil {
-			return "", errp
+			return "", newProtocolError("starting party", errp)
 		}
 
 		// return generated key share
-		ret := <-endCh
-		jsonRet, err := json.Marshal(ret)
+		ret, err := waitForResult(ctx, party.tssPartyState, "keygen", eddsaKeygenParty, endCh)
+		if err != nil {
+			return "", err
+		}
+		err = party.confirmGroupKey(ctx, "keygen", CurveEd25519, party.n, party.t, party.sortedParties, ret.EDDSAPub, nil)
 		if err != nil {
 			return "", err
 		}
-		return string(jsonRet), nil
+		return party.newKeyShare(CurveEd25519, party.n, party.t, party.sortedParties, ret.EDDSAPub, ret, nil)
 	})
 }
 
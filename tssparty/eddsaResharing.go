@@ -0,0 +1,99 @@
+package tssparty
+
+import (
+	"context"
+
+	"github.com/bnb-chain/tss-lib/v2/eddsa/keygen"
+	"github.com/bnb-chain/tss-lib/v2/eddsa/resharing"
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+// NewEddsaResharingTssParty creates a party of the old committee when jsonKeyShare
+// is given, or a party of the new committee when jsonKeyShare is empty
+func NewEddsaResharingTssParty(localID string, jsonKeyShare string, n int, t int, newN int, newT int) (ResharingTssParty, error) {
+	if jsonKeyShare == "" {
+		return &EddsaResharingTssPartyState{
+			resharingTssPartyState: NewResharingTssPartyState(NewPartyID(localID, nil), false, n, t, newN, newT).withCurve(CurveEd25519),
+		}, nil
+	}
+
+	share, err := ParseKeyShare(jsonKeyShare)
+	if err != nil {
+		return nil, err
+	}
+	localID, n, t, err = share.resolve(CurveEd25519, localID, n, t)
+	if err != nil {
+		return nil, err
+	}
+	key, err := JsonToEddsaKey(string(share.Share))
+	if err != nil {
+		return nil, err
+	}
+	err = share.checkShare(key.ShareID, key.Ks, key.EDDSAPub)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EddsaResharingTssPartyState{
+		resharingTssPartyState: NewResharingTssPartyState(NewPartyID(localID, key.ShareID), true, n, t, newN, newT).withCurve(CurveEd25519),
+		keyShare:               key,
+		envelope:               share,
+	}, nil
+}
+
+func (party *EddsaResharingTssPartyState) ReshareKey() (string, error) {
+	return party.ReshareKeyContext(context.Background())
+}
+
+func (party *EddsaResharingTssPartyState) ReshareKeyContext(ctx context.Context) (string, error) {
+	return party.stateFunc2(PEERS_KNOWN, TSS_DONE, func() (string, error) {
+		tssParams, err := party.GetResharingParams(tss.Edwards())
+		if err != nil {
+			return "", err
+		}
+
+		// old committee hands over its share, new committee starts from scratch
+		var key keygen.LocalPartySaveData
+		if party.oldCommittee {
+			key = *party.keyShare
+		} else {
+			key = keygen.NewLocalPartySaveData(party.newN)
+		}
+
+		outCh := make(chan tss.Message)
+		endCh := make(chan *keygen.LocalPartySaveData, 1)
+		defer party.stopProcessingMessages()
+		eddsaResharingParty := resharing.NewLocalParty(tssParams, key, outCh, endCh)
+
+		// start
+		party.outgoing.Add(1)
+		go party.ProcessOutgoingMessageToTransport(outCh)
+		go party.ProcessIncomingMessageFromTransport(eddsaResharingParty)
+		errp := eddsaResharingParty.Start()
+		if errp != nil {
+			return "", newProtocolError("starting party", errp)
+		}
+
+		// wait for the end of the resharing
+		ret, err := waitForResult(ctx, party.tssPartyState, "resharing", eddsaResharingParty, endCh)
+		if err != nil {
+			return "", err
+		}
+
+		// the old committee confirms the key it held
+		publicKey := ret.EDDSAPub
+		if party.oldCommittee {
+			publicKey = party.keyShare.EDDSAPub
+		}
+		err = party.confirmGroupKey(ctx, "resharing", CurveEd25519, party.newN, party.newT, party.newCommitteeParties(), publicKey, nil)
+		if err != nil {
+			return "", err
+		}
+
+		// old committee members do not receive a new share
+		if ret.Xi == nil {
+			return "", nil
+		}
+		return party.newKeyShare(CurveEd25519, party.newN, party.newT, party.newCommitteeParties(), ret.EDDSAPub, ret, nil)
+	})
+}
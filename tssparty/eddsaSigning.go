@@ -1,9 +1,8 @@
 package tssparty
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"math/big"
 
 	"github.com/bnb-chain/tss-lib/v2/common"
 	"github.com/bnb-chain/tss-lib/v2/eddsa/signing"
@@ -11,46 +10,109 @@ import (
 )
 
 func NewEddsaSigningTssParty(localID string, jsonKeyShare string, n int, t int) (SigningTssParty, error) {
-	key, err := JsonToEddsaKey(jsonKeyShare)
+	share, err := ParseKeyShare(jsonKeyShare)
+	if err != nil {
+		return nil, err
+	}
+	localID, n, t, err = share.resolve(CurveEd25519, localID, n, t)
+	if err != nil {
+		return nil, err
+	}
+	key, err := JsonToEddsaKey(string(share.Share))
+	if err != nil {
+		return nil, err
+	}
+	err = share.checkShare(key.ShareID, key.Ks, key.EDDSAPub)
 	if err != nil {
 		return nil, err
 	}
 
 	return &EddsaSigningTssPartyState{
-		tssPartyState: NewTssPartyState(NewPartyID(localID, key.ShareID), n, t),
+		tssPartyState: NewTssPartyState(NewPartyID(localID, key.ShareID), n, t).withCeremony(ProtocolSigning, CurveEd25519).withSigners(share.Roster, key.Ks),
 		keyShare:      key,
+		envelope:      share,
+		hashMode:      DefaultHashMode(CurveEd25519),
 	}, nil
 }
 
 func (party *EddsaSigningTssPartyState) SignMessage(msgToSign string) (string, error) {
-	return party.stateFunc2(PEERS_KNOWN, TSS_DONE, func() (string, error) {
-		// turn msg into a bigint
-		msg := new(big.Int)
-		if ret := msg.SetBytes([]byte(msgToSign)); ret == nil {
-			return "", fmt.Errorf("cannot convert msg into a big int")
+	return party.SignMessageContext(context.Background(), msgToSign)
+}
+
+func (party *EddsaSigningTssPartyState) SignMessageContext(ctx context.Context, msgToSign string) (string, error) {
+	signatures, err := party.SignMessagesContext(ctx, []string{msgToSign})
+	if err != nil {
+		return "", err
+	}
+	return signatures[0], nil
+}
+
+func (party *EddsaSigningTssPartyState) SignMessages(msgsToSign []string) ([]string, error) {
+	return party.SignMessagesContext(context.Background(), msgsToSign)
+}
+
+// SignMessagesContext signs a batch of messages over the current session, one tss party per message
+func (party *EddsaSigningTssPartyState) SignMessagesContext(ctx context.Context, msgsToSign []string) ([]string, error) {
+	var signatures []string
+	err := party.stateFunc(PEERS_KNOWN, TSS_DONE, func() error {
+		messages, digests, err := hashMessages(msgsToSign, party.hashMode, CurveEd25519)
+		if err != nil {
+			return err
+		}
+
+		intent, err := party.newSigningIntent(CurveEd25519, party.hashMode, nil, party.keyShare.EDDSAPub, messages)
+		if err != nil {
+			return err
+		}
+		err = party.approveSigning(ctx, intent)
+		if err != nil {
+			return err
 		}
 
-		outCh := make(chan tss.Message)
-		endCh := make(chan *common.SignatureData)
-		defer close(outCh)
-		defer close(endCh)
-		tssParams := party.GetParams(true)
-		eddsaSigningParty := signing.NewLocalParty(msg, tssParams, *party.keyShare, outCh, endCh)
-
-		// start
-		go party.ProcessOutgoingMessageToTransport(outCh)
-		go party.ProcessIncomingMessageFromTransport(eddsaSigningParty)
-		errp := eddsaSigningParty.Start()
-		if errp != nil {
-			return "", errp
+		err = party.confirmSigningIntent(ctx, CurveEd25519, party.hashMode, messages, party.keyShare.EDDSAPub)
+		if err != nil {
+			return err
 		}
 
-		// return signed message
-		ret := <-endCh
-		jsonRet, err := json.Marshal(ret)
+		rets, err := runBatch(ctx, party.tssPartyState, "signing", len(digests), func(i int, outCh chan<- tss.Message, endCh chan<- *common.SignatureData) tss.Party {
+			return signing.NewLocalParty(digests[i], party.GetParams(true), *party.keyShare, outCh, endCh)
+		})
 		if err != nil {
-			return "", err
+			return err
 		}
-		return string(jsonRet), nil
+
+		signatures = make([]string, len(rets))
+		for i, ret := range rets {
+			signatures[i], err = newSignature(CurveEd25519, party.hashMode, nil, ret)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return signatures, nil
+}
+
+func (party *EddsaSigningTssPartyState) SetHashMode(mode HashMode) error {
+	err := checkHashMode(mode, CurveEd25519)
+	if err != nil {
+		return err
+	}
+	party.hashMode = mode
+	return nil
+}
+
+// SetDerivationPath only accepts the master key, tss-lib derives child keys for ecdsa only
+func (party *EddsaSigningTssPartyState) SetDerivationPath(path string) error {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return err
+	}
+	if len(indexes) > 0 {
+		return fmt.Errorf("derivation paths are only supported for %s keys", CurveSecp256k1)
+	}
+	return nil
 }
@@ -0,0 +1,128 @@
+package tssparty
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func base58Encode(data []byte) string {
+	num := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var encoded []byte
+	for num.Sign() > 0 {
+		num.DivMod(num, radix, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	// each leading zero byte is written as the first digit
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append(encoded, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
+
+// base58CheckEncode appends the first 4 bytes of the double sha256 of version || payload
+func base58CheckEncode(version byte, payload []byte) string {
+	data := append([]byte{version}, payload...)
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return base58Encode(append(data, second[:4]...))
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for _, c := range hrp {
+		expanded = append(expanded, byte(c>>5))
+	}
+	expanded = append(expanded, 0)
+	for _, c := range hrp {
+		expanded = append(expanded, byte(c&31))
+	}
+	return expanded
+}
+
+// bech32Encode encodes 5-bit groups as defined by BIP173, or BIP350 when bech32m is set
+func bech32Encode(hrp string, data []byte, bech32m bool) string {
+	constant := uint32(bech32Const)
+	if bech32m {
+		constant = bech32mConst
+	}
+
+	values := append(bech32HrpExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ constant
+
+	var encoded strings.Builder
+	encoded.WriteString(hrp)
+	encoded.WriteByte('1')
+	for _, d := range data {
+		encoded.WriteByte(bech32Charset[d])
+	}
+	for i := 0; i < 6; i++ {
+		encoded.WriteByte(bech32Charset[(polymod>>(5*(5-i)))&31])
+	}
+	return encoded.String()
+}
+
+// convertBits regroups 8-bit bytes into 5-bit groups, padding the last one
+func convertBits(data []byte, fromBits uint, toBits uint) []byte {
+	var acc uint32
+	var bits uint
+	maxValue := uint32(1)<<toBits - 1
+
+	var converted []byte
+	for _, b := range data {
+		acc = acc<<fromBits | uint32(b)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			converted = append(converted, byte(acc>>bits&maxValue))
+		}
+	}
+	if bits > 0 {
+		converted = append(converted, byte(acc<<(toBits-bits)&maxValue))
+	}
+	return converted
+}
+
+// segwitAddress encodes a witness program as defined by BIP173 and BIP350
+func segwitAddress(hrp string, version byte, program []byte) (string, error) {
+	if version > 16 || len(program) < 2 || len(program) > 40 {
+		return "", fmt.Errorf("invalid witness program")
+	}
+	data := append([]byte{version}, convertBits(program, 8, 5)...)
+	return bech32Encode(hrp, data, version > 0), nil
+}
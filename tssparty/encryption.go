@@ -0,0 +1,99 @@
+package tssparty
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Point-to-point tss messages carry private shares, they are encrypted for their recipient
+// with a key agreed from the ephemeral x25519 keys advertised during the id exchange.
+
+const p2pKeyInfo = "tssparty p2p encryption v1"
+
+func (party *tssPartyState) newEncryptionKey() error {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	party.encryptionKey = key
+	party.peerCiphers = make(map[string]*p2pCipher)
+	return nil
+}
+
+func (party *tssPartyState) setPeerEncryptionKey(peerId string, peerKey []byte) error {
+	if len(peerKey) == 0 {
+		return fmt.Errorf("peer %s did not advertise an encryption key", peerId)
+	}
+	pub, err := ecdh.X25519().NewPublicKey(peerKey)
+	if err != nil {
+		return fmt.Errorf("peer %s advertised an invalid encryption key: %w", peerId, err)
+	}
+	shared, err := party.encryptionKey.ECDH(pub)
+	if err != nil {
+		return fmt.Errorf("cannot agree on a key with peer %s: %w", peerId, err)
+	}
+
+	// both ends derive the same key whatever the order of the public keys
+	localKey := party.encryptionKey.PublicKey().Bytes()
+	info := []byte(p2pKeyInfo)
+	if bytes.Compare(localKey, peerKey) < 0 {
+		info = append(append(info, localKey...), peerKey...)
+	} else {
+		info = append(append(info, peerKey...), localKey...)
+	}
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, []byte(party.sessionId), info), key); err != nil {
+		return err
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return err
+	}
+	party.peerCiphers[peerId] = &p2pCipher{aead: aead}
+	return nil
+}
+
+func (party *tssPartyState) encryptFor(to string, plaintext []byte) ([]byte, error) {
+	cipher, ok := party.peerCiphers[to]
+	if !ok {
+		return nil, fmt.Errorf("no encryption key for peer %s", to)
+	}
+	return cipher.seal(plaintext, p2pAdditionalData(party.thisParty.Id, to))
+}
+
+func (party *tssPartyState) decryptFrom(from string, ciphertext []byte) ([]byte, error) {
+	cipher, ok := party.peerCiphers[from]
+	if !ok {
+		return nil, fmt.Errorf("no encryption key for peer %s", from)
+	}
+	return cipher.open(ciphertext, p2pAdditionalData(from, party.thisParty.Id))
+}
+
+// the route is authenticated so that a message cannot be replayed to another recipient
+func p2pAdditionalData(from string, to string) []byte {
+	return []byte(fmt.Sprintf("%s\x00%s", from, to))
+}
+
+func (c *p2pCipher) seal(plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plaintext)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func (c *p2pCipher) open(ciphertext []byte, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	nonce, sealed := ciphertext[:c.aead.NonceSize()], ciphertext[c.aead.NonceSize():]
+	return c.aead.Open(nil, nonce, sealed, additionalData)
+}
@@ -0,0 +1,208 @@
+package tssparty
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tamperingTransport lets tamper rewrite the messages sent to the peers of to, nil for a broadcast.
+// The messages tamper returns nil for are dropped.
+type tamperingTransport struct {
+	Transport
+	tamper func(to []string, msg []byte) []byte
+}
+
+func (transport *tamperingTransport) Unicast(to string, msg []byte) error {
+	return transport.Multicast([]string{to}, msg)
+}
+
+func (transport *tamperingTransport) Multicast(to []string, msg []byte) error {
+	if msg = transport.tamper(to, msg); msg == nil {
+		return nil
+	}
+	return transport.Transport.Multicast(to, msg)
+}
+
+func (transport *tamperingTransport) Broadcast(msg []byte) error {
+	if msg = transport.tamper(nil, msg); msg == nil {
+		return nil
+	}
+	return transport.Transport.Broadcast(msg)
+}
+
+// tamperTssMessages rewrites the tss messages of a tamperingTransport with edit, or drops them
+// when edit returns false
+func tamperTssMessages(edit func(to []string, msg *peerMessage) bool) func(to []string, msg []byte) []byte {
+	return func(to []string, msg []byte) []byte {
+		var envelope peerMessage
+		if json.Unmarshal(msg, &envelope) != nil || envelope.Type != TSS_MESSAGE {
+			return msg
+		}
+		if !edit(to, &envelope) {
+			return nil
+		}
+		tampered, err := json.Marshal(envelope)
+		if err != nil {
+			panic(err)
+		}
+		return tampered
+	}
+}
+
+func newTestEncryptionParty(t *testing.T, id string) *tssPartyState {
+	t.Helper()
+	party := NewTssPartyState(NewPartyID(id, nil), 0, 0)
+	party.sessionId = "session"
+	err := party.newEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return party
+}
+
+func TestP2PCipher(t *testing.T) {
+	alice := newTestEncryptionParty(t, "alice")
+	bob := newTestEncryptionParty(t, "bob")
+	carol := newTestEncryptionParty(t, "carol")
+	for _, party := range []*tssPartyState{alice, bob, carol} {
+		for _, peer := range []*tssPartyState{alice, bob, carol} {
+			if party == peer {
+				continue
+			}
+			err := party.setPeerEncryptionKey(peer.thisParty.Id, peer.encryptionKey.PublicKey().Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	ciphertext, err := alice.encryptFor("bob", []byte("private share"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(ciphertext, []byte("private share")) {
+		t.Fatal("the ciphertext holds the plaintext")
+	}
+	plaintext, err := bob.decryptFrom("alice", ciphertext)
+	if err != nil || string(plaintext) != "private share" {
+		t.Fatalf("bob decrypted %q: %v", plaintext, err)
+	}
+
+	tampered := slices.Clone(ciphertext)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := bob.decryptFrom("alice", tampered); err == nil {
+		t.Error("bob decrypted a tampered message")
+	}
+	if _, err := carol.decryptFrom("alice", ciphertext); err == nil {
+		t.Error("carol decrypted a message sent to bob")
+	}
+	if _, err := bob.decryptFrom("carol", ciphertext); err == nil {
+		t.Error("bob decrypted a message of alice as coming from carol")
+	}
+	if err := bob.setPeerEncryptionKey("dave", nil); err == nil {
+		t.Error("accepted a peer without encryption key")
+	}
+	if err := bob.setPeerEncryptionKey("dave", []byte("short")); err == nil {
+		t.Error("accepted an invalid encryption key")
+	}
+}
+
+func TestP2PMessagesAreEncrypted(t *testing.T) {
+	hub := NewMemoryHub()
+	var lock sync.Mutex
+	p2p := 0
+	shares, errs := runParties(3, func(i int) (string, error) {
+		party, err := newTestKeygenParty(t, CurveEd25519, i, 3, 1)
+		if err != nil {
+			return "", err
+		}
+		transport := &tamperingTransport{hub.NewTransport(), tamperTssMessages(func(to []string, msg *peerMessage) bool {
+			if msg.IsBroadcast {
+				return true
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			p2p++
+			if !msg.Encrypted || len(to) != 1 {
+				t.Errorf("party %v sent a point-to-point message in the clear to %v", i, to)
+			}
+			return true
+		})}
+		return ConnectAndGetKeyShareWithTransport(party, transport, "keygen")
+	})
+	requireNoErrors(t, errs)
+	if p2p == 0 {
+		t.Fatal("the keygen sent no point-to-point message")
+	}
+	if _, err := ParseKeyShare(shares[0]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRejectedP2PMessages(t *testing.T) {
+	tests := []struct {
+		name      string
+		edit      func(msg *peerMessage)
+		operation string
+	}{
+		{"tampered", func(msg *peerMessage) { msg.Payload[len(msg.Payload)-1] ^= 1 }, "decrypting message"},
+		{"unencrypted", func(msg *peerMessage) { msg.Encrypted = false }, "receiving message"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := NewMemoryHub()
+			_, errs := runParties(3, func(i int) (string, error) {
+				party, err := newTestKeygenParty(t, CurveEd25519, i, 3, 1)
+				if err != nil {
+					return "", err
+				}
+				party.SetTimeouts(Timeouts{Round: 2 * time.Second})
+				transport := hub.NewTransport()
+				if i == 2 {
+					transport = &tamperingTransport{transport, tamperTssMessages(func(to []string, msg *peerMessage) bool {
+						if !msg.IsBroadcast {
+							test.edit(msg)
+						}
+						return true
+					})}
+				}
+				return ConnectAndGetKeyShareWithTransport(party, transport, "keygen")
+			})
+
+			// the peers of p2 blame it, p2 itself times out waiting for them
+			for _, err := range errs[:2] {
+				var protocolErr *ProtocolError
+				if !errors.As(err, &protocolErr) {
+					t.Fatalf("expected a protocol error, got: %v", err)
+				}
+				if protocolErr.Operation != test.operation || !slices.Equal(protocolErr.Culprits, []string{"p2"}) {
+					t.Fatalf("unexpected protocol error: %s", protocolErr)
+				}
+			}
+			var timeoutErr *TimeoutError
+			if !errors.As(errs[2], &timeoutErr) {
+				t.Fatalf("expected p2 to time out, got: %v", errs[2])
+			}
+		})
+	}
+}
+
+func TestEncryptionRequiresRoster(t *testing.T) {
+	hub := NewMemoryHub()
+	party := NewEddsaKeygenTssParty("p0", 3, 1)
+	_, err := ConnectAndGetKeyShareWithTransport(party, hub.NewTransport(), "keygen")
+	if !errors.Is(err, ErrNoRoster) {
+		t.Fatalf("expected ErrNoRoster, got: %v", err)
+	}
+
+	coordinator := NewCoordinator("p0")
+	err = coordinator.Connect(hub.NewTransport(), "lobby")
+	if !errors.Is(err, ErrNoRoster) {
+		t.Fatalf("expected the coordinator to fail with ErrNoRoster, got: %v", err)
+	}
+}
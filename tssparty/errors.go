@@ -0,0 +1,62 @@
+package tssparty
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+// ProtocolError is returned when the ceremony is aborted by a failure of the protocol,
+// Culprits lists the ids of the parties blamed for it
+type ProtocolError struct {
+	Task      string
+	Round     int
+	Operation string
+	Culprits  []string
+	Err       error
+}
+
+func (err *ProtocolError) Error() string {
+	msg := fmt.Sprintf("%s failed at round %d while %s", err.Task, err.Round, err.Operation)
+	if len(err.Culprits) > 0 {
+		msg += fmt.Sprintf(", culprits: [ %s ]", strings.Join(err.Culprits, ", "))
+	}
+	return fmt.Sprintf("%s: %s", msg, err.Err.Error())
+}
+
+func (err *ProtocolError) Unwrap() error {
+	return err.Err
+}
+
+// newProtocolError wraps err, the task, round and culprits of a tss-lib error take precedence
+func newProtocolError(operation string, err error, culprits ...string) *ProtocolError {
+	protocolErr := &ProtocolError{
+		Operation: operation,
+		Culprits:  culprits,
+		Err:       err,
+	}
+
+	var tssErr *tss.Error
+	if errors.As(err, &tssErr) {
+		protocolErr.Task = tssErr.Task()
+		protocolErr.Round = tssErr.Round()
+		if len(tssErr.Culprits()) > 0 {
+			protocolErr.Culprits = MapArrayOfPartyID(tssErr.Culprits(), func(p *tss.PartyID) string { return p.Id })
+		}
+		if tssErr.Cause() != nil {
+			protocolErr.Err = tssErr.Cause()
+		}
+	}
+	return protocolErr
+}
+
+// fail aborts the ceremony, only the first failure is reported
+func (party *tssPartyState) fail(err *ProtocolError) {
+	logger.Errorf("%s", err.Error())
+	select {
+	case party.failures <- err:
+	default:
+	}
+}
@@ -0,0 +1,96 @@
+package tssparty
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bnb-chain/tss-lib/v2/eddsa/keygen"
+	"github.com/bnb-chain/tss-lib/v2/tss"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// withoutCommitment empties the commitment of an eddsa keygen round 1 message, the other
+// messages are left untouched
+func withoutCommitment(t *testing.T, wireBytes []byte) []byte {
+	var message anypb.Any
+	var content keygen.KGRound1Message
+	if proto.Unmarshal(wireBytes, &message) != nil || message.UnmarshalTo(&content) != nil {
+		return wireBytes
+	}
+	content.Commitment = nil
+	err := message.MarshalFrom(&content)
+	if err != nil {
+		t.Error(err)
+		return wireBytes
+	}
+	tampered, err := proto.Marshal(&message)
+	if err != nil {
+		t.Error(err)
+		return wireBytes
+	}
+	return tampered
+}
+
+func TestProtocolErrorCulprits(t *testing.T) {
+	tests := []struct {
+		name      string
+		corrupt   func(t *testing.T, wireBytes []byte) []byte
+		operation string
+	}{
+		{"invalid message", withoutCommitment, "processing message"},
+		{"garbled message", func(t *testing.T, wireBytes []byte) []byte { return []byte("garbled") }, "parsing message"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := NewMemoryHub()
+			_, errs := runParties(3, func(i int) (string, error) {
+				party, err := newTestKeygenParty(t, CurveEd25519, i, 3, 1)
+				if err != nil {
+					return "", err
+				}
+				party.SetTimeouts(Timeouts{Round: 2 * time.Second})
+				transport := hub.NewTransport()
+				if i == 2 {
+					// the first message of p2 is its round 1 broadcast
+					transport = &tamperingTransport{transport, tamperTssMessages(func(to []string, msg *peerMessage) bool {
+						if msg.IsBroadcast {
+							msg.Payload = test.corrupt(t, msg.Payload)
+						}
+						return true
+					})}
+				}
+				return ConnectAndGetKeyShareWithTransport(party, transport, "keygen")
+			})
+
+			for i, err := range errs[:2] {
+				var protocolErr *ProtocolError
+				if !errors.As(err, &protocolErr) {
+					t.Fatalf("party %v: expected a protocol error, got: %v", i, err)
+				}
+				if protocolErr.Operation != test.operation || !slices.Equal(protocolErr.Culprits, []string{"p2"}) {
+					t.Fatalf("party %v: unexpected protocol error: %s", i, protocolErr)
+				}
+			}
+		})
+	}
+}
+
+func TestNewProtocolError(t *testing.T) {
+	culprit := tss.NewPartyID("p1", "p1", NewPartyID("p1", nil).KeyInt())
+	cause := errors.New("bad share")
+	err := newProtocolError("processing message", tss.NewError(cause, "keygen", 2, nil, culprit), "p0")
+	if err.Task != "keygen" || err.Round != 2 || !slices.Equal(err.Culprits, []string{"p1"}) || !errors.Is(err, cause) {
+		t.Fatalf("the tss-lib error was not unwrapped: %#v", err)
+	}
+	if err.Error() != "keygen failed at round 2 while processing message, culprits: [ p1 ]: bad share" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+
+	err = newProtocolError("decrypting message", cause, "p0")
+	if !slices.Equal(err.Culprits, []string{"p0"}) {
+		t.Fatalf("expected p0 to be blamed, got %v", err.Culprits)
+	}
+}
@@ -0,0 +1,98 @@
+package tssparty
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// HashMode tells how the message given to a signing ceremony turns into the signed value
+type HashMode string
+
+const (
+	HashModeDigestHex    HashMode = "digest-hex"    // the message is a hex encoded digest, signed as is
+	HashModeDigestBase64 HashMode = "digest-base64" // the message is a base64 encoded digest, signed as is
+	HashModeSha256       HashMode = "sha256"
+	HashModeKeccak256    HashMode = "keccak256"
+	HashModeSha256d      HashMode = "sha256d" // sha256 applied twice, as used by bitcoin
+	HashModeSha512       HashMode = "sha512"  // truncated to its leftmost 32 bytes for ecdsa
+	HashModeEddsa        HashMode = "eddsa"   // the full message, hashed by eddsa itself as defined by RFC 8032
+)
+
+func ParseHashMode(mode string) (HashMode, error) {
+	switch HashMode(mode) {
+	case HashModeDigestHex, HashModeDigestBase64, HashModeSha256, HashModeKeccak256, HashModeSha256d, HashModeSha512, HashModeEddsa:
+		return HashMode(mode), nil
+	}
+	return "", fmt.Errorf("unknown hash mode %s", mode)
+}
+
+// DefaultHashMode is sha256 for ecdsa and the full message for eddsa
+func DefaultHashMode(curve string) HashMode {
+	if curve == CurveEd25519 {
+		return HashModeEddsa
+	}
+	return HashModeSha256
+}
+
+func checkHashMode(mode HashMode, curve string) error {
+	_, err := ParseHashMode(string(mode))
+	if err != nil {
+		return err
+	}
+	if mode == HashModeEddsa && curve != CurveEd25519 {
+		return fmt.Errorf("hash mode %s is only available for %s", mode, CurveEd25519)
+	}
+	return nil
+}
+
+// HashMessage returns the value that is signed for message
+func HashMessage(message []byte, mode HashMode, curve string) ([]byte, error) {
+	err := checkHashMode(mode, curve)
+	if err != nil {
+		return nil, err
+	}
+
+	var digest []byte
+	switch mode {
+	case HashModeDigestHex:
+		digest, err = hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(message)), "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode hex digest: %w", err)
+		}
+	case HashModeDigestBase64:
+		digest, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(message)))
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode base64 digest: %w", err)
+		}
+	case HashModeSha256:
+		sum := sha256.Sum256(message)
+		digest = sum[:]
+	case HashModeKeccak256:
+		hash := sha3.NewLegacyKeccak256()
+		hash.Write(message)
+		digest = hash.Sum(nil)
+	case HashModeSha256d:
+		sum := sha256.Sum256(message)
+		sum = sha256.Sum256(sum[:])
+		digest = sum[:]
+	case HashModeSha512:
+		sum := sha512.Sum512(message)
+		digest = sum[:]
+		if curve != CurveEd25519 {
+			digest = digest[:32]
+		}
+	case HashModeEddsa:
+		return message, nil
+	}
+
+	if curve != CurveEd25519 && len(digest) != 32 {
+		return nil, fmt.Errorf("expected a 32 bytes digest but got %v bytes", len(digest))
+	}
+	return digest, nil
+}
@@ -0,0 +1,76 @@
+package tssparty
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestHashMessage(t *testing.T) {
+	const sha256Abc = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	const sha512Abc = "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"
+	tests := []struct {
+		message string
+		mode    HashMode
+		curve   string
+		want    string // hex, empty when an error is expected
+	}{
+		{"abc", HashModeSha256, CurveSecp256k1, sha256Abc},
+		{"abc", HashModeKeccak256, CurveSecp256k1, "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45"},
+		{"abc", HashModeSha256d, CurveSecp256k1, "4f8b42c22dd3729b519ba6f68d2da7cc5b2d606d05daed5ad5128cc03e6c6358"},
+		{"abc", HashModeSha512, CurveSecp256k1, sha512Abc[:64]},
+		{"abc", HashModeSha512, CurveEd25519, sha512Abc},
+		{"abc", HashModeEddsa, CurveEd25519, hex.EncodeToString([]byte("abc"))},
+		{"abc", HashModeEddsa, CurveSecp256k1, ""},
+		{"0x" + sha256Abc, HashModeDigestHex, CurveSecp256k1, sha256Abc},
+		{" " + sha256Abc + "\n", HashModeDigestHex, CurveSecp256k1, sha256Abc},
+		{"ungWv48Bz+pBQUDeXa4iI7ADYaOWF3qctBD/YfIAFa0=", HashModeDigestBase64, CurveSecp256k1, sha256Abc},
+		{"abcd", HashModeDigestHex, CurveSecp256k1, ""}, // too short for ecdsa
+		{"abcd", HashModeDigestHex, CurveEd25519, "abcd"},
+		{"not hex", HashModeDigestHex, CurveEd25519, ""},
+		{"not base64", HashModeDigestBase64, CurveEd25519, ""},
+		{"abc", "md5", CurveSecp256k1, ""},
+	}
+	for _, test := range tests {
+		digest, err := HashMessage([]byte(test.message), test.mode, test.curve)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%s of %q on %s: expected an error", test.mode, test.message, test.curve)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s of %q on %s: %s", test.mode, test.message, test.curve, err)
+			continue
+		}
+		if hex.EncodeToString(digest) != test.want {
+			t.Errorf("%s of %q on %s: got %x, want %s", test.mode, test.message, test.curve, digest, test.want)
+		}
+	}
+}
+
+func TestSigningHashMode(t *testing.T) {
+	hub := NewMemoryHub()
+	shares := testKeygen(t, hub, CurveEd25519, 3, 1)
+
+	signatures, errs := runParties(2, func(i int) (string, error) {
+		party, err := newTestSigningParty(shares[i])
+		if err != nil {
+			return "", err
+		}
+		err = party.SetHashMode(HashModeSha256)
+		if err != nil {
+			return "", err
+		}
+		return ConnectAndSignMessageWithTransport(party, hub.NewTransport(), "signing", "abc")
+	})
+	requireNoErrors(t, errs)
+
+	signature, err := JsonToSignature(signatures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature.HashMode != HashModeSha256 {
+		t.Fatalf("the signature tells hash mode %s", signature.HashMode)
+	}
+	requireValidSignature(t, shares[0], "abc", signatures[0])
+}
@@ -0,0 +1,170 @@
+package tssparty
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Parties are given a long-term identity key and a roster of the peers they expect.
+// Announcements are then signed, and the id exchange only accepts peers of the roster
+// whose announcement is signed by the identity key pinned for them.
+
+const announcementSignatureContext = "tssparty announcement v1"
+
+// ErrNoRoster is returned by a party without roster, unless it was explicitly made insecure
+var ErrNoRoster = errors.New("no roster to authenticate the peers and their encryption keys")
+
+type RosterEntry struct {
+	Id          string            `json:"id"`
+	IdentityKey ed25519.PublicKey `json:"identityKey"`
+}
+
+// signedAnnouncement is the payload of an announcement message, and of the other broadcast
+// messages exchanged outside of the tss protocol
+type signedAnnouncement struct {
+	Announcement []byte `json:"announcement"`
+	Signature    []byte `json:"signature,omitempty"`
+}
+
+func (party *tssPartyState) SetIdentity(identityKey ed25519.PrivateKey) error {
+	if len(identityKey) != ed25519.PrivateKeySize {
+		return fmt.Errorf("identity key must be %v bytes long", ed25519.PrivateKeySize)
+	}
+	party.identityKey = identityKey
+	return nil
+}
+
+func (party *tssPartyState) SetRoster(roster []RosterEntry) error {
+	rosterMap := make(map[string]ed25519.PublicKey)
+	for _, entry := range roster {
+		if len(entry.IdentityKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("identity key of roster entry %s must be %v bytes long", entry.Id, ed25519.PublicKeySize)
+		}
+		if _, exists := rosterMap[entry.Id]; exists {
+			return fmt.Errorf("roster entry %s is duplicated", entry.Id)
+		}
+		rosterMap[entry.Id] = entry.IdentityKey
+	}
+	party.roster = rosterMap
+	return nil
+}
+
+// SetInsecure lets the party run without a roster. Its peers and their encryption keys are then
+// not authenticated: the bus can impersonate them and read the private shares sent to them.
+func (party *tssPartyState) SetInsecure(insecure bool) {
+	party.insecure = insecure
+}
+
+// checkIdentity makes sure that the local party can take part in a ceremony pinned to a roster
+func (party *tssPartyState) checkIdentity() error {
+	if party.roster == nil {
+		if !party.insecure {
+			return ErrNoRoster
+		}
+		// the encryption keys are then as trustworthy as the bus, which can swap them for its own
+		logger.Warnf("no roster, the peers and their encryption keys are not authenticated")
+		return nil
+	}
+	pinnedKey, ok := party.roster[party.thisParty.Id]
+	if !ok {
+		return fmt.Errorf("local party %s is not part of the roster", party.thisParty.Id)
+	}
+	if party.identityKey == nil {
+		return fmt.Errorf("an identity key is required to join a ceremony with a roster")
+	}
+	if !pinnedKey.Equal(party.identityKey.Public()) {
+		return fmt.Errorf("identity key of local party %s does not match the roster", party.thisParty.Id)
+	}
+	return nil
+}
+
+func (party *tssPartyState) signAnnouncement(announcement []byte) ([]byte, error) {
+	return party.signPeerMessage(announcementSignatureContext, announcement)
+}
+
+// openAnnouncement checks the announcement of a peer against the roster, if any
+func (party *tssPartyState) openAnnouncement(from string, payload []byte) ([]byte, error) {
+	return party.openPeerMessage(announcementSignatureContext, "announcement", from, payload)
+}
+
+// signPeerMessage wraps a broadcast payload along with its signature by the identity key, if any
+func (party *tssPartyState) signPeerMessage(signatureContext string, content []byte) ([]byte, error) {
+	signed := signedAnnouncement{Announcement: content}
+	if party.identityKey != nil {
+		signed.Signature = ed25519.Sign(party.identityKey, party.signingPayload(signatureContext, content))
+	}
+	return json.Marshal(signed)
+}
+
+// openPeerMessage unwraps a broadcast payload, checking its signature against the roster if any
+func (party *tssPartyState) openPeerMessage(signatureContext string, kind string, from string, payload []byte) ([]byte, error) {
+	var signed signedAnnouncement
+	err := json.Unmarshal(payload, &signed)
+	if err != nil {
+		return nil, err
+	}
+	if party.roster == nil {
+		return signed.Announcement, nil
+	}
+
+	pinnedKey, ok := party.roster[from]
+	if !ok {
+		return nil, fmt.Errorf("peer %s is not part of the roster", from)
+	}
+	if len(signed.Signature) == 0 {
+		return nil, fmt.Errorf("%s of peer %s is not signed", kind, from)
+	}
+	if !ed25519.Verify(pinnedKey, party.signingPayload(signatureContext, signed.Announcement), signed.Signature) {
+		return nil, fmt.Errorf("%s of peer %s is not signed by its identity key, peer may be impersonated", kind, from)
+	}
+	return signed.Announcement, nil
+}
+
+// the signature is bound to the session so that a message cannot be replayed elsewhere
+func (party *tssPartyState) signingPayload(signatureContext string, content []byte) []byte {
+	payload := []byte(signatureContext)
+	payload = append(payload, 0)
+	payload = append(payload, []byte(party.sessionId)...)
+	payload = append(payload, 0)
+	return append(payload, content...)
+}
+
+func NewIdentityKey() (ed25519.PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(nil)
+	return key, err
+}
+
+// LoadIdentityKey reads an identity key stored as a hex encoded ed25519 seed
+func LoadIdentityKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("identity seed must be %v bytes long", ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// LoadRoster reads a json array of roster entries
+func LoadRoster(path string) ([]RosterEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var roster []RosterEntry
+	err = json.Unmarshal(data, &roster)
+	if err != nil {
+		return nil, err
+	}
+	return roster, nil
+}
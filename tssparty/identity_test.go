@@ -0,0 +1,119 @@
+package tssparty
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testRoster generates the identity keys of the given ids and their roster
+func testRoster(t *testing.T, ids ...string) (map[string]ed25519.PrivateKey, []RosterEntry) {
+	t.Helper()
+	keys := make(map[string]ed25519.PrivateKey)
+	var roster []RosterEntry
+	for _, id := range ids {
+		key, err := NewIdentityKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[id] = key
+		roster = append(roster, RosterEntry{Id: id, IdentityKey: key.Public().(ed25519.PublicKey)})
+	}
+	return keys, roster
+}
+
+func TestRosterPinning(t *testing.T) {
+	keys, roster := testRoster(t, "p0", "p1", "p2")
+	rogueKey, err := NewIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		id       string             // of the third party
+		key      ed25519.PrivateKey // identity key of the third party
+		rejected string             // error of p0 and p1, empty when the third party is accepted
+	}{
+		{"pinned peer", "p2", keys["p2"], ""},
+		{"key not in the roster", "p2", rogueKey, "not signed by its identity key"},
+		{"impersonated peer", "p2", keys["p1"], "not signed by its identity key"},
+		{"peer outside the roster", "p3", rogueKey, "not part of the roster"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := NewMemoryHub()
+			_, errs := runParties(3, func(i int) (string, error) {
+				id := fmt.Sprintf("p%d", i)
+				key, partyRoster := keys[id], roster
+				if i == 2 {
+					// the third party pins its own key, so that it passes its own checks
+					id, key = test.id, test.key
+					partyRoster = append(slices.Clone(roster[:2]), RosterEntry{Id: id, IdentityKey: key.Public().(ed25519.PublicKey)})
+				}
+				party := NewEddsaKeygenTssParty(id, 3, 1)
+				party.SetTimeouts(Timeouts{IdExchange: 2 * time.Second, Round: 2 * time.Second})
+				err := party.SetIdentity(key)
+				if err != nil {
+					return "", err
+				}
+				err = party.SetRoster(partyRoster)
+				if err != nil {
+					return "", err
+				}
+				return ConnectAndGetKeyShareWithTransport(party, hub.NewTransport(), "keygen")
+			})
+
+			if test.rejected == "" {
+				requireNoErrors(t, errs)
+				return
+			}
+			for i, err := range errs[:2] {
+				if err == nil || !strings.Contains(err.Error(), test.rejected) {
+					t.Fatalf("party %v: expected the third party to be rejected with %q, got: %v", i, test.rejected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalIdentity(t *testing.T) {
+	keys, roster := testRoster(t, "p0", "p1")
+	tests := []struct {
+		name    string
+		id      string
+		key     ed25519.PrivateKey
+		invalid string
+	}{
+		{"pinned", "p0", keys["p0"], ""},
+		{"outside the roster", "p2", keys["p0"], "not part of the roster"},
+		{"without identity key", "p0", nil, "an identity key is required"},
+		{"key of another party", "p0", keys["p1"], "does not match the roster"},
+	}
+	for _, test := range tests {
+		party := NewTssPartyState(NewPartyID(test.id, nil), 2, 1)
+		if test.key != nil {
+			if err := party.SetIdentity(test.key); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := party.SetRoster(roster); err != nil {
+			t.Fatal(err)
+		}
+		err := party.checkIdentity()
+		if test.invalid == "" && err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+		if test.invalid != "" && (err == nil || !strings.Contains(err.Error(), test.invalid)) {
+			t.Errorf("%s: expected %q, got: %v", test.name, test.invalid, err)
+		}
+	}
+
+	duplicated := append(slices.Clone(roster), roster[0])
+	if err := NewTssPartyState(NewPartyID("p0", nil), 2, 1).SetRoster(duplicated); err == nil {
+		t.Error("accepted a roster with a duplicated entry")
+	}
+}
@@ -0,0 +1,194 @@
+package tssparty
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/bnb-chain/tss-lib/v2/crypto"
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+const KeyShareVersion = 1
+
+const (
+	CurveSecp256k1 = "secp256k1"
+	CurveEd25519   = "ed25519"
+)
+
+// KeyShare wraps the share of a party with the parameters of the ceremony that produced it
+type KeyShare struct {
+	Version   int             `json:"version"`
+	Curve     string          `json:"curve"`
+	N         int             `json:"n"`
+	T         int             `json:"t"`
+	PartyId   string          `json:"partyId"`
+	Roster    []string        `json:"roster"`              // party ids, in the order of the share ids
+	PublicKey string          `json:"publicKey"`           // hex, compressed SEC1 for secp256k1, RFC 8032 for ed25519
+	ChainCode string          `json:"chainCode,omitempty"` // hex, BIP32 chain code of the group key, secp256k1 only
+	CreatedAt time.Time       `json:"createdAt"`
+	SessionId string          `json:"sessionId"`
+	Share     json.RawMessage `json:"share"`
+}
+
+// ParseKeyShare reads a key share envelope, a bare tss-lib share is returned
+// wrapped in an envelope of version 0 that carries no metadata
+func ParseKeyShare(jsonKeyShare string) (*KeyShare, error) {
+	var share KeyShare
+	err := json.Unmarshal([]byte(jsonKeyShare), &share)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse key share: %w", err)
+	}
+
+	if share.Version == 0 && share.Share == nil {
+		return &KeyShare{Share: json.RawMessage(jsonKeyShare)}, nil
+	}
+	if share.Version != KeyShareVersion {
+		return nil, fmt.Errorf("unsupported key share version %v", share.Version)
+	}
+	if share.Curve != CurveSecp256k1 && share.Curve != CurveEd25519 {
+		return nil, fmt.Errorf("unsupported key share curve %s", share.Curve)
+	}
+	if share.T <= 0 || share.T >= share.N {
+		return nil, fmt.Errorf("key share threshold %v is out of range for %v parties", share.T, share.N)
+	}
+	if len(share.Roster) != share.N {
+		return nil, fmt.Errorf("key share roster lists %v parties but n is %v", len(share.Roster), share.N)
+	}
+	if len(toSet(share.Roster)) != len(share.Roster) {
+		return nil, fmt.Errorf("key share roster lists a party twice")
+	}
+	if share.PartyId == "" || !slices.Contains(share.Roster, share.PartyId) {
+		return nil, fmt.Errorf("key share owner %s is not in its roster", share.PartyId)
+	}
+	publicKey, err := hex.DecodeString(share.PublicKey)
+	if err == nil {
+		_, err = ParsePublicKey(share.Curve, publicKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key share holds an invalid public key: %w", err)
+	}
+	if share.ChainCode != "" {
+		chainCode, err := hex.DecodeString(share.ChainCode)
+		if err != nil || len(chainCode) != chainCodeSize || share.Curve != CurveSecp256k1 {
+			return nil, fmt.Errorf("key share holds an invalid chain code")
+		}
+	}
+	return &share, nil
+}
+
+// resolve checks the envelope against the parameters given by the caller, an empty
+// localID or a zero n or t is taken from the envelope
+func (share *KeyShare) resolve(curve string, localID string, n int, t int) (string, int, int, error) {
+	if share.Version == 0 {
+		if localID == "" || n == 0 || t == 0 {
+			return "", 0, 0, fmt.Errorf("party id, n and t are required with a key share without metadata")
+		}
+		return localID, n, t, nil
+	}
+
+	if share.Curve != curve {
+		return "", 0, 0, fmt.Errorf("key share is for curve %s, not %s", share.Curve, curve)
+	}
+	if localID == "" {
+		localID = share.PartyId
+	} else if localID != share.PartyId {
+		return "", 0, 0, fmt.Errorf("key share belongs to party %s, not %s", share.PartyId, localID)
+	}
+	if n == 0 {
+		n = share.N
+	} else if n != share.N {
+		return "", 0, 0, fmt.Errorf("key share was generated for %v parties, not %v", share.N, n)
+	}
+	if t == 0 {
+		t = share.T
+	} else if t != share.T {
+		return "", 0, 0, fmt.Errorf("key share was generated with threshold %v, not %v", share.T, t)
+	}
+	return localID, n, t, nil
+}
+
+// checkShare makes sure the wrapped tss-lib share matches the envelope
+func (share *KeyShare) checkShare(shareID *big.Int, ks []*big.Int, publicKey *crypto.ECPoint) error {
+	if shareID == nil || publicKey == nil {
+		return fmt.Errorf("key share is incomplete")
+	}
+	if share.Version == 0 {
+		return nil
+	}
+
+	if len(ks) != share.N {
+		return fmt.Errorf("key share holds %v share ids but n is %v", len(ks), share.N)
+	}
+	if ks[slices.Index(share.Roster, share.PartyId)].Cmp(shareID) != 0 {
+		return fmt.Errorf("key share id does not match the position of %s in the roster", share.PartyId)
+	}
+	if hex.EncodeToString(encodePublicKey(share.Curve, publicKey)) != share.PublicKey {
+		return fmt.Errorf("key share public key does not match its envelope")
+	}
+	return nil
+}
+
+// chainCodeBytes returns the chain code of the envelope, nil when the key has none
+func (share *KeyShare) chainCodeBytes() []byte {
+	chainCode, _ := hex.DecodeString(share.ChainCode)
+	if len(chainCode) == 0 {
+		return nil
+	}
+	return chainCode
+}
+
+// newKeyShare wraps the output of a ceremony, parties are the members of the committee holding the key
+func (party *tssPartyState) newKeyShare(curve string, n int, t int, parties []*tss.PartyID, publicKey *crypto.ECPoint, saveData any, chainCode []byte) (string, error) {
+	data, err := json.Marshal(saveData)
+	if err != nil {
+		return "", err
+	}
+
+	share := KeyShare{
+		Version:   KeyShareVersion,
+		Curve:     curve,
+		N:         n,
+		T:         t,
+		PartyId:   party.thisParty.Id,
+		Roster:    rosterIds(parties),
+		PublicKey: hex.EncodeToString(encodePublicKey(curve, publicKey)),
+		ChainCode: hex.EncodeToString(chainCode),
+		CreatedAt: time.Now().UTC(),
+		SessionId: party.sessionId,
+		Share:     data,
+	}
+	jsonShare, err := json.Marshal(share)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonShare), nil
+}
+
+// rosterIds lists the ids of parties in the order of their share ids, it sorts a copy as
+// tss.SortPartyIDs would reindex party ids that the running tss party reads
+func rosterIds(parties []*tss.PartyID) []string {
+	sorted := slices.Clone(parties)
+	slices.SortFunc(sorted, func(a, b *tss.PartyID) int { return a.KeyInt().Cmp(b.KeyInt()) })
+	return MapArrayOfPartyID(sorted, func(p *tss.PartyID) string { return p.Id })
+}
+
+// encodePublicKey returns the compressed SEC1 encoding of a secp256k1 point
+// or the RFC 8032 encoding of an ed25519 point
+func encodePublicKey(curve string, publicKey *crypto.ECPoint) []byte {
+	if curve == CurveEd25519 {
+		encoded := make([]byte, 32)
+		publicKey.Y().FillBytes(encoded)
+		slices.Reverse(encoded)
+		encoded[31] |= byte(publicKey.X().Bit(0)) << 7
+		return encoded
+	}
+
+	encoded := make([]byte, 33)
+	encoded[0] = 2 + byte(publicKey.Y().Bit(0))
+	publicKey.X().FillBytes(encoded[1:])
+	return encoded
+}
@@ -0,0 +1,89 @@
+package tssparty
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseKeyShare(t *testing.T) {
+	valid := KeyShare{
+		Version: KeyShareVersion,
+		Curve:   CurveEd25519,
+		N:       3,
+		T:       1,
+		PartyId: "bob",
+		Roster:  []string{"alice", "bob", "carol"},
+		// the public key of the first test vector of RFC 8032
+		PublicKey: "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a",
+		SessionId: "keygen",
+		Share:     json.RawMessage(`{}`),
+	}
+
+	tests := []struct {
+		name   string
+		change func(share *KeyShare)
+		valid  bool
+	}{
+		{"valid", func(share *KeyShare) {}, true},
+		{"unknown version", func(share *KeyShare) { share.Version = 2 }, false},
+		{"unknown curve", func(share *KeyShare) { share.Curve = "p256" }, false},
+		{"zero threshold", func(share *KeyShare) { share.T = 0 }, false},
+		{"negative threshold", func(share *KeyShare) { share.T = -1 }, false},
+		{"threshold of n", func(share *KeyShare) { share.T = 3 }, false},
+		{"roster shorter than n", func(share *KeyShare) { share.Roster = []string{"alice", "bob"} }, false},
+		{"duplicated roster entry", func(share *KeyShare) { share.Roster = []string{"alice", "bob", "bob"} }, false},
+		{"owner outside the roster", func(share *KeyShare) { share.PartyId = "dave" }, false},
+		{"no owner", func(share *KeyShare) { share.PartyId = ""; share.Roster = []string{"alice", "bob", ""} }, false},
+		{"public key not in hex", func(share *KeyShare) { share.PublicKey = "not hex" }, false},
+		{"truncated public key", func(share *KeyShare) { share.PublicKey = share.PublicKey[:62] }, false},
+		{"public key of another curve", func(share *KeyShare) { share.Curve = CurveSecp256k1 }, false},
+		{"chain code on ed25519", func(share *KeyShare) { share.ChainCode = "00" }, false},
+	}
+	for _, test := range tests {
+		share := valid
+		test.change(&share)
+		encoded, err := json.Marshal(share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseKeyShare(string(encoded))
+		if test.valid && (err != nil || parsed.PartyId != share.PartyId) {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected the key share to be rejected", test.name)
+		}
+	}
+
+	// a bare tss-lib share has no metadata
+	bare, err := ParseKeyShare(`{"Xi": 1}`)
+	if err != nil || bare.Version != 0 || string(bare.Share) != `{"Xi": 1}` {
+		t.Errorf("bare share parsed as %+v: %v", bare, err)
+	}
+	if _, err := ParseKeyShare(`not json`); err == nil {
+		t.Error("parsed an invalid key share")
+	}
+}
+
+func TestKeyShareResolve(t *testing.T) {
+	share := &KeyShare{Version: KeyShareVersion, Curve: CurveEd25519, N: 3, T: 1, PartyId: "bob"}
+	id, n, threshold, err := share.resolve(CurveEd25519, "", 0, 0)
+	if err != nil || id != "bob" || n != 3 || threshold != 1 {
+		t.Fatalf("resolved %s %v %v: %v", id, n, threshold, err)
+	}
+	mismatches := []struct {
+		curve string
+		id    string
+		n, t  int
+	}{
+		{CurveSecp256k1, "", 0, 0},
+		{CurveEd25519, "alice", 0, 0},
+		{CurveEd25519, "", 4, 0},
+		{CurveEd25519, "", 0, 2},
+	}
+	for _, mismatch := range mismatches {
+		if _, _, _, err := share.resolve(mismatch.curve, mismatch.id, mismatch.n, mismatch.t); err == nil {
+			t.Errorf("resolved %+v against the envelope", mismatch)
+		}
+	}
+}
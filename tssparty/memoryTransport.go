@@ -0,0 +1,148 @@
+package tssparty
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// MemoryHub relays messages between transports living in the same process.
+// It behaves like a go-partybus server and lets full ceremonies run between goroutines.
+type MemoryHub struct {
+	mutex    sync.Mutex
+	sessions map[string]map[string]*MemoryTransport
+}
+
+// MemoryTransport is a Transport connected to a MemoryHub
+type MemoryTransport struct {
+	hub       *MemoryHub
+	sessionId string
+	localId   string
+	in        *queue[TransportMessage]
+	sig       *queue[PresenceStatus]
+}
+
+func NewMemoryHub() *MemoryHub {
+	return &MemoryHub{
+		sessions: make(map[string]map[string]*MemoryTransport),
+	}
+}
+
+func (hub *MemoryHub) NewTransport() Transport {
+	return &MemoryTransport{
+		hub: hub,
+	}
+}
+
+func (hub *MemoryHub) join(transport *MemoryTransport) error {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	session, ok := hub.sessions[transport.sessionId]
+	if !ok {
+		session = make(map[string]*MemoryTransport)
+		hub.sessions[transport.sessionId] = session
+	}
+	if _, exists := session[transport.localId]; exists {
+		return fmt.Errorf("peer %s is already in session %s", transport.localId, transport.sessionId)
+	}
+	session[transport.localId] = transport
+	hub.notifyPresence(transport.sessionId)
+	return nil
+}
+
+func (hub *MemoryHub) leave(transport *MemoryTransport) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	session := hub.sessions[transport.sessionId]
+	delete(session, transport.localId)
+	if len(session) == 0 {
+		delete(hub.sessions, transport.sessionId)
+		return
+	}
+	hub.notifyPresence(transport.sessionId)
+}
+
+// notifyPresence must be called with the hub mutex held
+func (hub *MemoryHub) notifyPresence(sessionId string) {
+	session := hub.sessions[sessionId]
+	peers := make([]string, 0, len(session))
+	for id := range session {
+		peers = append(peers, id)
+	}
+	sort.Strings(peers)
+
+	for _, peer := range session {
+		peer.sig.Push(PresenceStatus{Peers: peers})
+	}
+}
+
+// deliver sends msg to the peers of to, or to every other peer of the session when to is nil
+func (hub *MemoryHub) deliver(sessionId string, from string, to []string, msg []byte) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	for id, peer := range hub.sessions[sessionId] {
+		if to == nil && id == from {
+			continue
+		}
+		if to != nil && !slices.Contains(to, id) {
+			continue
+		}
+		peer.in.Push(TransportMessage{From: from, Msg: msg})
+	}
+}
+
+func (transport *MemoryTransport) Connect(sessionId string, localId string) error {
+	if transport.in != nil {
+		return fmt.Errorf("transport is already connected")
+	}
+	transport.sessionId = sessionId
+	transport.localId = localId
+	transport.in = newQueue[TransportMessage]()
+	transport.sig = newQueue[PresenceStatus]()
+	return transport.hub.join(transport)
+}
+
+func (transport *MemoryTransport) Unicast(to string, msg []byte) error {
+	return transport.Multicast([]string{to}, msg)
+}
+
+func (transport *MemoryTransport) Multicast(to []string, msg []byte) error {
+	if len(to) == 0 {
+		return fmt.Errorf("multicast message needs at least one recipient")
+	}
+	return transport.send(to, msg)
+}
+
+func (transport *MemoryTransport) Broadcast(msg []byte) error {
+	return transport.send(nil, msg)
+}
+
+func (transport *MemoryTransport) Receive() <-chan TransportMessage {
+	return transport.in.Out()
+}
+
+func (transport *MemoryTransport) Presence() <-chan PresenceStatus {
+	return transport.sig.Out()
+}
+
+func (transport *MemoryTransport) Close() error {
+	if transport.in == nil {
+		return nil
+	}
+	transport.hub.leave(transport)
+	transport.in.Close()
+	transport.sig.Close()
+	return nil
+}
+
+func (transport *MemoryTransport) send(to []string, msg []byte) error {
+	if transport.in == nil {
+		return fmt.Errorf("transport is not connected")
+	}
+	transport.hub.deliver(transport.sessionId, transport.localId, to, msg)
+	return nil
+}
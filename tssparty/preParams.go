@@ -0,0 +1,84 @@
+package tssparty
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnb-chain/tss-lib/v2/ecdsa/keygen"
+)
+
+// GeneratePreParams computes the paillier key and the safe primes an ecdsa party needs to take part
+// in a keygen or to join a resharing. This takes from seconds to minutes, so they can be generated
+// ahead of time and given to NewEcdsaKeygenTssPartyWithPreParams. Concurrency bounds the number
+// of cores used, zero uses them all.
+func GeneratePreParams(ctx context.Context, concurrency int) (string, error) {
+	var preParams *keygen.LocalPreParams
+	var err error
+	if concurrency > 0 {
+		preParams, err = keygen.GeneratePreParamsWithContext(ctx, concurrency)
+	} else {
+		preParams, err = keygen.GeneratePreParamsWithContext(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+	jsonPreParams, err := json.Marshal(preParams)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonPreParams), nil
+}
+
+// JsonToEcdsaPreParams reads pre-parameters produced by GeneratePreParams and checks them
+func JsonToEcdsaPreParams(jsonPreParams string) (*keygen.LocalPreParams, error) {
+	var preParams keygen.LocalPreParams
+	err := json.Unmarshal([]byte(jsonPreParams), &preParams)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse preparams: %w", err)
+	}
+	if !preParams.ValidateWithProof() {
+		return nil, fmt.Errorf("preparams are incomplete")
+	}
+	return &preParams, nil
+}
+
+// defaultPreParamsTimeout bounds the preparams a party computes when Timeouts.PreParams is zero
+const defaultPreParamsTimeout = 1 * time.Minute
+
+// preParamsProgress is how often the generation of preparams logs that it is still running
+const preParamsProgress = 10 * time.Second
+
+// generatePreParams computes the paillier keys and safe primes, giving up after timeout or the default one
+func generatePreParams(ctx context.Context, timeout time.Duration) (*keygen.LocalPreParams, error) {
+	if timeout <= 0 {
+		timeout = defaultPreParamsTimeout
+	}
+	logger.Infof("computing preparams, this can take up to %s...", timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(preParamsProgress)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				logger.Infof("still computing preparams after %s", time.Since(start).Round(time.Second))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	preParams, err := keygen.GeneratePreParamsWithContext(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("cannot compute preparams in %s, raise the preparams timeout or generate them ahead of time: %w", timeout, err)
+	}
+	return preParams, err
+}
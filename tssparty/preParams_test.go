@@ -0,0 +1,29 @@
+package tssparty
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreParamsTimeout(t *testing.T) {
+	party := NewEcdsaKeygenTssParty("p0", 3, 1)
+	party.SetTimeouts(Timeouts{PreParams: 10 * time.Millisecond})
+	start := time.Now()
+	err := party.Init()
+	if err == nil || !strings.Contains(err.Error(), "cannot compute preparams in 10ms") {
+		t.Fatalf("expected the preparams to time out, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("the preparams timed out after %s", elapsed)
+	}
+}
+
+func TestJsonToEcdsaPreParams(t *testing.T) {
+	if _, err := JsonToEcdsaPreParams(preParamsFor(t, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := JsonToEcdsaPreParams(`{}`); err == nil {
+		t.Error("accepted incomplete preparams")
+	}
+}
@@ -0,0 +1,252 @@
+package tssparty
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/bnb-chain/tss-lib/v2/tss"
+	"golang.org/x/crypto/ripemd160"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	oidEcPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSecp256k1   = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// PublicKey is the group public key of a key share
+type PublicKey struct {
+	Curve string
+	key   []byte // compressed SEC1 for secp256k1, RFC 8032 for ed25519
+	x, y  *big.Int
+}
+
+// ParsePublicKey reads a SEC1 secp256k1 public key or an RFC 8032 ed25519 public key
+func ParsePublicKey(curve string, encoded []byte) (*PublicKey, error) {
+	switch curve {
+	case CurveSecp256k1:
+		pub, err := parseSecp256k1PublicKey(encoded)
+		if err != nil {
+			return nil, err
+		}
+		key := make([]byte, 33)
+		key[0] = 2 + byte(pub.Y.Bit(0))
+		pub.X.FillBytes(key[1:])
+		return &PublicKey{Curve: curve, key: key, x: pub.X, y: pub.Y}, nil
+
+	case CurveEd25519:
+		if len(encoded) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("expected a %v bytes ed25519 public key but got %v bytes", ed25519.PublicKeySize, len(encoded))
+		}
+		return &PublicKey{Curve: curve, key: append([]byte{}, encoded...)}, nil
+	}
+	return nil, fmt.Errorf("unsupported curve %s", curve)
+}
+
+// KeySharePublicKey returns the group public key of a key share, it also reads shares without an envelope
+func KeySharePublicKey(jsonKeyShare string) (*PublicKey, error) {
+	share, err := ParseKeyShare(jsonKeyShare)
+	if err != nil {
+		return nil, err
+	}
+
+	if share.Version != 0 {
+		encoded, err := hex.DecodeString(share.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		return ParsePublicKey(share.Curve, encoded)
+	}
+
+	if ecdsaKey, err := JsonToEcdsaKey(string(share.Share)); err == nil && ecdsaKey.ECDSAPub != nil {
+		return ParsePublicKey(CurveSecp256k1, encodePublicKey(CurveSecp256k1, ecdsaKey.ECDSAPub))
+	}
+	if eddsaKey, err := JsonToEddsaKey(string(share.Share)); err == nil && eddsaKey.EDDSAPub != nil {
+		return ParsePublicKey(CurveEd25519, encodePublicKey(CurveEd25519, eddsaKey.EDDSAPub))
+	}
+	return nil, fmt.Errorf("key share holds no public key")
+}
+
+// Bytes returns the compressed SEC1 encoding for secp256k1, the RFC 8032 encoding for ed25519
+func (pub *PublicKey) Bytes() []byte {
+	return append([]byte{}, pub.key...)
+}
+
+func (pub *PublicKey) Hex() string {
+	return hex.EncodeToString(pub.key)
+}
+
+func (pub *PublicKey) Base58() string {
+	return base58Encode(pub.key)
+}
+
+// Uncompressed returns the uncompressed SEC1 encoding of a secp256k1 public key
+func (pub *PublicKey) Uncompressed() ([]byte, error) {
+	if pub.Curve != CurveSecp256k1 {
+		return nil, fmt.Errorf("an uncompressed encoding is only available for %s", CurveSecp256k1)
+	}
+	encoded := make([]byte, 65)
+	encoded[0] = 4
+	pub.x.FillBytes(encoded[1:33])
+	pub.y.FillBytes(encoded[33:])
+	return encoded, nil
+}
+
+// JWK returns the public key as a JSON Web Key, defined by RFC 8812 for secp256k1 and RFC 8037 for ed25519
+func (pub *PublicKey) JWK() ([]byte, error) {
+	encode := base64.RawURLEncoding.EncodeToString
+	if pub.Curve == CurveEd25519 {
+		return json.Marshal(map[string]string{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"x":   encode(pub.key),
+		})
+	}
+	return json.Marshal(map[string]string{
+		"kty": "EC",
+		"crv": "secp256k1",
+		"x":   encode(pub.x.FillBytes(make([]byte, 32))),
+		"y":   encode(pub.y.FillBytes(make([]byte, 32))),
+	})
+}
+
+// PEM returns the PEM encoded SubjectPublicKeyInfo of the public key
+func (pub *PublicKey) PEM() ([]byte, error) {
+	var der []byte
+	var err error
+	if pub.Curve == CurveEd25519 {
+		der, err = x509.MarshalPKIXPublicKey(ed25519.PublicKey(pub.key))
+	} else {
+		// x509 does not know secp256k1
+		uncompressed, _ := pub.Uncompressed()
+		der, err = asn1.Marshal(struct {
+			Algorithm struct {
+				Algorithm  asn1.ObjectIdentifier
+				Parameters asn1.ObjectIdentifier
+			}
+			PublicKey asn1.BitString
+		}{
+			Algorithm: struct {
+				Algorithm  asn1.ObjectIdentifier
+				Parameters asn1.ObjectIdentifier
+			}{oidEcPublicKey, oidSecp256k1},
+			PublicKey: asn1.BitString{Bytes: uncompressed, BitLength: len(uncompressed) * 8},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+func (pub *PublicKey) requireCurve(curve string, chain string) error {
+	if pub.Curve != curve {
+		return fmt.Errorf("%s addresses require a %s public key", chain, curve)
+	}
+	return nil
+}
+
+func hash160(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	hash := ripemd160.New()
+	hash.Write(sum[:])
+	return hash.Sum(nil)
+}
+
+// EthereumAddress returns the EIP-55 checksummed address of a secp256k1 public key
+func (pub *PublicKey) EthereumAddress() (string, error) {
+	if err := pub.requireCurve(CurveSecp256k1, "ethereum"); err != nil {
+		return "", err
+	}
+	uncompressed, _ := pub.Uncompressed()
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(uncompressed[1:])
+	return checksumAddress(hash.Sum(nil)[12:]), nil
+}
+
+// checksumAddress encodes a 20 bytes ethereum address with the EIP-55 checksum
+func checksumAddress(address []byte) string {
+	encoded := []byte(hex.EncodeToString(address))
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(encoded)
+	checksum := hash.Sum(nil)
+	for i, c := range encoded {
+		if c >= 'a' && (checksum[i/2]>>(4*(1-i%2)))&0xf >= 8 {
+			encoded[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(encoded)
+}
+
+// BitcoinP2PKHAddress returns the legacy mainnet address of the compressed public key
+func (pub *PublicKey) BitcoinP2PKHAddress() (string, error) {
+	if err := pub.requireCurve(CurveSecp256k1, "bitcoin"); err != nil {
+		return "", err
+	}
+	return base58CheckEncode(0x00, hash160(pub.key)), nil
+}
+
+// BitcoinP2WPKHAddress returns the native segwit mainnet address of the compressed public key
+func (pub *PublicKey) BitcoinP2WPKHAddress() (string, error) {
+	if err := pub.requireCurve(CurveSecp256k1, "bitcoin"); err != nil {
+		return "", err
+	}
+	return segwitAddress("bc", 0, hash160(pub.key))
+}
+
+// BitcoinP2TRAddress returns the mainnet taproot address of the public key used as internal key,
+// without script path as specified by BIP86
+func (pub *PublicKey) BitcoinP2TRAddress() (string, error) {
+	if err := pub.requireCurve(CurveSecp256k1, "bitcoin"); err != nil {
+		return "", err
+	}
+	curve := tss.S256()
+
+	// the internal key is the point with an even y
+	x := pub.x
+	y := new(big.Int).Set(pub.y)
+	if y.Bit(0) == 1 {
+		y.Sub(curve.Params().P, y)
+	}
+
+	tweak := taggedHash("TapTweak", x.FillBytes(make([]byte, 32)))
+	if new(big.Int).SetBytes(tweak).Cmp(curve.Params().N) >= 0 {
+		return "", fmt.Errorf("invalid taproot tweak")
+	}
+	tx, ty := curve.ScalarBaseMult(tweak)
+	qx, _ := curve.Add(x, y, tx, ty)
+	return segwitAddress("bc", 1, qx.FillBytes(make([]byte, 32)))
+}
+
+// CosmosAddress returns the bech32 address of the public key with the human readable part of a cosmos chain
+func (pub *PublicKey) CosmosAddress(hrp string) (string, error) {
+	if err := pub.requireCurve(CurveSecp256k1, "cosmos"); err != nil {
+		return "", err
+	}
+	return bech32Encode(hrp, convertBits(hash160(pub.key), 8, 5), false), nil
+}
+
+// SolanaAddress returns the base58 encoding of an ed25519 public key
+func (pub *PublicKey) SolanaAddress() (string, error) {
+	if err := pub.requireCurve(CurveEd25519, "solana"); err != nil {
+		return "", err
+	}
+	return base58Encode(pub.key), nil
+}
+
+func taggedHash(tag string, data []byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	hash := sha256.New()
+	hash.Write(tagHash[:])
+	hash.Write(tagHash[:])
+	hash.Write(data)
+	return hash.Sum(nil)
+}
@@ -0,0 +1,124 @@
+package tssparty
+
+import (
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func mustParsePublicKey(t *testing.T, curve string, encoded string) *PublicKey {
+	t.Helper()
+	key, err := hex.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParsePublicKey(curve, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub
+}
+
+func TestSecp256k1PublicKey(t *testing.T) {
+	// the generator, public key of the private key 1
+	const x = "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	const y = "483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"
+	pub := mustParsePublicKey(t, CurveSecp256k1, "04"+x+y)
+
+	if pub.Hex() != "02"+x {
+		t.Errorf("compressed key %s", pub.Hex())
+	}
+	uncompressed, err := pub.Uncompressed()
+	if err != nil || hex.EncodeToString(uncompressed) != "04"+x+y {
+		t.Errorf("uncompressed key %x: %v", uncompressed, err)
+	}
+	jwk, err := pub.JWK()
+	if err != nil || string(jwk) != `{"crv":"secp256k1","kty":"EC","x":"eb5mfvncu6xVoGKVzocLBwKb_NstzijZWfKBWxb4F5g","y":"SDradyajxGVdpPv8DhEIqP0XtEimhVQZnEfQj_sQ1Lg"}` {
+		t.Errorf("jwk %s: %v", jwk, err)
+	}
+	encoded, err := pub.PEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(encoded)
+	if block == nil || hex.EncodeToString(block.Bytes) != "3056301006072a8648ce3d020106052b8104000a034200"+"04"+x+y {
+		t.Errorf("pem %s", encoded)
+	}
+
+	addresses := []struct {
+		name    string
+		address func() (string, error)
+		want    string
+	}{
+		{"ethereum", pub.EthereumAddress, "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"},
+		{"bitcoin p2pkh", pub.BitcoinP2PKHAddress, "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"},
+		{"bitcoin p2wpkh", pub.BitcoinP2WPKHAddress, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		{"cosmos", func() (string, error) { return pub.CosmosAddress("cosmos") }, "cosmos1w508d6qejxtdg4y5r3zarvary0c5xw7k6ah60c"},
+		{"solana", pub.SolanaAddress, ""},
+	}
+	for _, test := range addresses {
+		address, err := test.address()
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", test.name, address)
+			}
+			continue
+		}
+		if err != nil || address != test.want {
+			t.Errorf("%s: got %s, want %s: %v", test.name, address, test.want, err)
+		}
+	}
+
+	// the first receiving address of BIP86
+	taproot := mustParsePublicKey(t, CurveSecp256k1, "02cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115")
+	address, err := taproot.BitcoinP2TRAddress()
+	if err != nil || address != "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr" {
+		t.Errorf("bitcoin p2tr: got %s: %v", address, err)
+	}
+}
+
+func TestEd25519PublicKey(t *testing.T) {
+	// the public key of the first test vector of RFC 8032
+	const key = "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"
+	pub := mustParsePublicKey(t, CurveEd25519, key)
+
+	address, err := pub.SolanaAddress()
+	if err != nil || address != "FVen3X669xLzsi6N2V91DoiyzHzg1uAgqiT8jZ9nS96Z" {
+		t.Errorf("solana: got %s: %v", address, err)
+	}
+	jwk, err := pub.JWK()
+	if err != nil || string(jwk) != `{"crv":"Ed25519","kty":"OKP","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}` {
+		t.Errorf("jwk %s: %v", jwk, err)
+	}
+	encoded, err := pub.PEM()
+	if err != nil || !strings.Contains(string(encoded), "MCowBQYDK2VwAyEA11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=") {
+		t.Errorf("pem %s: %v", encoded, err)
+	}
+	if _, err := pub.EthereumAddress(); err == nil {
+		t.Error("derived an ethereum address from an ed25519 key")
+	}
+	if _, err := pub.Uncompressed(); err == nil {
+		t.Error("encoded an ed25519 key as uncompressed SEC1")
+	}
+}
+
+func TestParsePublicKeyRejects(t *testing.T) {
+	tests := []struct {
+		curve   string
+		encoded string
+	}{
+		{CurveSecp256k1, "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f817"},                                                                   // truncated
+		{CurveSecp256k1, "0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b9"}, // not on the curve
+		{CurveSecp256k1, "05" + strings.Repeat("00", 32)},
+		{CurveEd25519, "d75a98"},
+		{"p256", "02" + strings.Repeat("00", 32)},
+	}
+	for _, test := range tests {
+		encoded, _ := hex.DecodeString(test.encoded)
+		_, err := ParsePublicKey(test.curve, encoded)
+		if err == nil {
+			t.Errorf("accepted %s key %s", test.curve, test.encoded)
+		}
+	}
+}
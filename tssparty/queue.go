@@ -0,0 +1,76 @@
+package tssparty
+
+import (
+	"sync"
+)
+
+// queue is an unbounded fifo feeding a channel, so that producers never block
+// on a consumer that is busy with another step of the ceremony
+type queue[T any] struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	items  []T
+	closed bool
+	out    chan T
+	done   chan struct{}
+}
+
+func newQueue[T any]() *queue[T] {
+	q := &queue[T]{
+		out:  make(chan T),
+		done: make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mutex)
+	go q.forward()
+	return q
+}
+
+func (q *queue[T]) Out() <-chan T {
+	return q.out
+}
+
+func (q *queue[T]) Push(item T) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return false
+	}
+	q.items = append(q.items, item)
+	q.cond.Signal()
+	return true
+}
+
+// Close stops the delivery of pending items and closes the output channel
+func (q *queue[T]) Close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.done)
+	q.cond.Signal()
+}
+
+func (q *queue[T]) forward() {
+	defer close(q.out)
+	for {
+		q.mutex.Lock()
+		for len(q.items) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.closed {
+			q.mutex.Unlock()
+			return
+		}
+		item := q.items[0]
+		q.items = q.items[1:]
+		q.mutex.Unlock()
+
+		select {
+		case q.out <- item:
+		case <-q.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package tssparty
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+// ErrStandBy is returned to a holder that was not selected to sign
+var ErrStandBy = errors.New("not selected to sign, standing by")
+
+// signerSet restricts a signing ceremony to the holders of the key share and selects
+// the quorum of signers among the holders in the session
+type signerSet struct {
+	roster     []string // holder ids in the order of ks, empty for a share without metadata
+	ks         []*big.Int
+	preference []string
+	quorum     []string // sorted
+	claimed    map[string]string
+	late       []string // holders that announced themselves outside of the quorum
+	standBy    bool     // the local party is not in the quorum
+	approve    Approval
+}
+
+func (party *tssPartyState) withSigners(roster []string, ks []*big.Int) *tssPartyState {
+	party.signers = &signerSet{roster: roster, ks: ks}
+	return party
+}
+
+// SetSignerPreference gives the holders to select first, in order, the other holders
+// follow in the order of the roster. All the holders must be given the same preference.
+func (party *tssPartyState) SetSignerPreference(ids []string) error {
+	if party.signers == nil {
+		return fmt.Errorf("signer preference only applies to signing")
+	}
+	for i, id := range ids {
+		if slices.Contains(ids[:i], id) {
+			return fmt.Errorf("signer %s is listed twice", id)
+		}
+		if len(party.signers.roster) > 0 && !slices.Contains(party.signers.roster, id) {
+			return fmt.Errorf("signer %s does not hold a share of the key", id)
+		}
+	}
+	party.signers.preference = ids
+	return nil
+}
+
+// holdersIn filters the guests that hold a share of the key, all of them for a share without metadata
+func (signers *signerSet) holdersIn(guests []string) []string {
+	if len(signers.roster) == 0 {
+		return guests
+	}
+	return slices.DeleteFunc(slices.Clone(guests), func(id string) bool { return !slices.Contains(signers.roster, id) })
+}
+
+// enoughHolders tells whether a quorum of size can be selected among the guests, the preferred
+// holders that fit in the quorum are waited for so that every holder selects the same quorum
+func (signers *signerSet) enoughHolders(guests []string, size int) bool {
+	holders := signers.holdersIn(guests)
+	for _, id := range signers.preference[:min(size, len(signers.preference))] {
+		if !slices.Contains(holders, id) {
+			return false
+		}
+	}
+	return len(holders) >= size
+}
+
+// selectQuorum picks size holders among the guests, the preferred ones first then in the order of the roster
+func (party *tssPartyState) selectQuorum(guests []string, size int) error {
+	signers := party.signers
+	if size <= party.t || size > party.n {
+		return fmt.Errorf("a quorum of %v signers cannot sign with threshold %v and %v shares", size, party.t, party.n)
+	}
+
+	holders := signers.holdersIn(guests)
+	order := slices.Clone(signers.roster)
+	if len(order) == 0 {
+		order = slices.Clone(holders)
+		slices.Sort(order)
+	}
+	order = append(slices.Clone(signers.preference), order...)
+
+	var quorum []string
+	for _, id := range order {
+		if len(quorum) < size && slices.Contains(holders, id) && !slices.Contains(quorum, id) {
+			quorum = append(quorum, id)
+		}
+	}
+	if len(quorum) < size {
+		return fmt.Errorf("only %v holders of the key joined the session, %v are required", len(quorum), size)
+	}
+	slices.Sort(quorum)
+	logger.Infof("selected signers: [ %s ]", strings.Join(quorum, ", "))
+
+	signers.quorum = quorum
+	signers.standBy = !slices.Contains(quorum, party.thisParty.Id)
+	signers.claimed = map[string]string{party.thisParty.KeyInt().String(): party.thisParty.Id}
+	return nil
+}
+
+// quorum is the selected signers announced to the peers, nil outside of signing
+func (party *tssPartyState) quorum() []string {
+	if party.signers == nil {
+		return nil
+	}
+	return party.signers.quorum
+}
+
+// inQuorum tells whether the announcement of a peer is expected
+func (party *tssPartyState) inQuorum(id string) bool {
+	return party.signers == nil || slices.Contains(party.signers.quorum, id)
+}
+
+// checkSigner makes sure a signer holds the share the roster assigns to it and selected the same quorum
+func (party *tssPartyState) checkSigner(peer *tss.PartyID, quorum []string) error {
+	signers := party.signers
+	if signers == nil {
+		return nil
+	}
+
+	key := peer.KeyInt()
+	if len(signers.roster) > 0 {
+		if signers.ks[slices.Index(signers.roster, peer.Id)].Cmp(key) != 0 {
+			return fmt.Errorf("peer %s does not hold the share assigned to it by the roster", peer.Id)
+		}
+	} else if !slices.ContainsFunc(signers.ks, func(k *big.Int) bool { return k.Cmp(key) == 0 }) {
+		return fmt.Errorf("peer %s does not hold a share of the key", peer.Id)
+	}
+	if other, ok := signers.claimed[key.String()]; ok {
+		return fmt.Errorf("peer %s claims the share of %s", peer.Id, other)
+	}
+	signers.claimed[key.String()] = peer.Id
+
+	if !slices.Equal(quorum, signers.quorum) {
+		if slices.Contains(quorum, peer.Id) && !slices.Contains(quorum, party.thisParty.Id) {
+			return fmt.Errorf("%w, peer %s signs with [ %s ]", ErrStandBy, peer.Id, strings.Join(quorum, ", "))
+		}
+		return fmt.Errorf("peer %s selected the signers [ %s ] but the local party selected [ %s ]", peer.Id, strings.Join(quorum, ", "), strings.Join(signers.quorum, ", "))
+	}
+	return nil
+}
+
+// outsiderAnnouncement handles the announcement of a peer outside of the quorum. A signer that
+// already started tells the local party to stand by, other holders are answered once the quorum starts.
+func (party *tssPartyState) outsiderAnnouncement(msg inboundMessage) error {
+	announcementJson, err := party.openAnnouncement(msg.From, msg.Payload)
+	if err != nil {
+		logger.Errorf("ignoring the announcement of peer %s: %s", msg.From, err.Error())
+		return nil
+	}
+	var announcement partyAnnouncement
+	err = json.Unmarshal(announcementJson, &announcement)
+	if err != nil || announcement.PartyID == nil || announcement.PartyID.Id != msg.From {
+		logger.Errorf("ignoring a malformed announcement of peer %s", msg.From)
+		return nil
+	}
+
+	if announcement.Started {
+		return fmt.Errorf("%w, peer %s already signs with [ %s ]", ErrStandBy, msg.From, strings.Join(announcement.Signers, ", "))
+	}
+	if !slices.Contains(announcement.Signers, msg.From) {
+		logger.Debugf("peer %s stands by", msg.From)
+		return nil
+	}
+	logger.Debugf("peer %s is not a selected signer", msg.From)
+	if !slices.Contains(party.signers.late, msg.From) {
+		party.signers.late = append(party.signers.late, msg.From)
+	}
+	return nil
+}
+
+// answerLateHolders tells the holders announcing themselves outside of the quorum that the signers
+// already started, as they missed the announcements sent before they joined
+func (party *tssPartyState) answerLateHolders() {
+	started, err := party.announcement(true)
+	if err != nil {
+		logger.Errorf("cannot answer late holders: %s", err.Error())
+		return
+	}
+
+	answer := func(peerId string) {
+		err := party.send(ANNOUNCEMENT_MESSAGE, []string{peerId}, false, started)
+		if err != nil {
+			logger.Debugf("cannot answer late holder %s: %s", peerId, err.Error())
+		}
+	}
+	answered := make(map[string]bool)
+	for _, peerId := range party.signers.late {
+		answered[peerId] = true
+		answer(peerId)
+	}
+	for msg := range party.receive(ANNOUNCEMENT_MESSAGE) {
+		if !party.inQuorum(msg.From) && !answered[msg.From] {
+			answered[msg.From] = true
+			answer(msg.From)
+		}
+	}
+}
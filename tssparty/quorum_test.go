@@ -0,0 +1,165 @@
+package tssparty
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// presenceFilter hides the presence updates listing fewer than minPeers peers, and closes
+// forwarded once it forwarded one
+type presenceFilter struct {
+	Transport
+	minPeers  int
+	forwarded chan struct{}
+	presence  chan PresenceStatus
+}
+
+func (filter *presenceFilter) Connect(sessionId string, localId string) error {
+	err := filter.Transport.Connect(sessionId, localId)
+	if err != nil {
+		return err
+	}
+	filter.presence = make(chan PresenceStatus)
+	go func() {
+		defer close(filter.presence)
+		var once sync.Once
+		for status := range filter.Transport.Presence() {
+			if len(status.Peers) < filter.minPeers {
+				continue
+			}
+			filter.presence <- status
+			once.Do(func() { close(filter.forwarded) })
+		}
+	}()
+	return nil
+}
+
+func (filter *presenceFilter) Presence() <-chan PresenceStatus {
+	return filter.presence
+}
+
+func TestSignersSelectTheSameQuorum(t *testing.T) {
+	hub := NewMemoryHub()
+	shares := testKeygen(t, hub, CurveEd25519, 4, 1)
+
+	// all the holders of a 2-of-4 key join, two of them sign and the others stand by
+	signatures := testSign(t, hub, shares, "signing", "hello world")
+	signed := 0
+	for _, signature := range signatures {
+		if signature != "" {
+			signed++
+		}
+	}
+	if signed != 2 {
+		t.Fatalf("expected 2 signers but %v signed", signed)
+	}
+}
+
+func TestSignerPreference(t *testing.T) {
+	hub := NewMemoryHub()
+	shares := testKeygen(t, hub, CurveEd25519, 3, 1)
+	share, err := ParseKeyShare(shares[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	preferred := []string{share.Roster[2], share.Roster[0]}
+
+	signatures, errs := runParties(3, func(i int) (string, error) {
+		party, err := newTestSigningParty(shares[i])
+		if err != nil {
+			return "", err
+		}
+		err = party.SetSignerPreference(preferred)
+		if err != nil {
+			return "", err
+		}
+		signature, err := ConnectAndSignMessageWithTransport(party, hub.NewTransport(), "signing", "hello world")
+		if errors.Is(err, ErrStandBy) {
+			return "", nil
+		}
+		return signature, err
+	})
+	requireNoErrors(t, errs)
+	for i, keyShare := range shares {
+		share, err := ParseKeyShare(keyShare)
+		if err != nil {
+			t.Fatal(err)
+		}
+		isPreferred := share.PartyId == preferred[0] || share.PartyId == preferred[1]
+		if isPreferred != (signatures[i] != "") {
+			t.Fatalf("party %s signed: %v, preferred: %v", share.PartyId, signatures[i] != "", isPreferred)
+		}
+	}
+}
+
+// TestDivergentQuorums makes two holders select different quorums: the first holder of the roster
+// selects itself and the third one before the second one joins, while the third one waits for all
+// of them and selects the first two. The ceremony must fail instead of waiting forever.
+func TestDivergentQuorums(t *testing.T) {
+	hub := NewMemoryHub()
+	shares := testKeygen(t, hub, CurveEd25519, 3, 1)
+	share, err := ParseKeyShare(shares[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	byId := make(map[string]string)
+	for _, keyShare := range shares {
+		holder, err := ParseKeyShare(keyShare)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byId[holder.PartyId] = keyShare
+	}
+	first, second, third := share.Roster[0], share.Roster[1], share.Roster[2]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	sign := func(id string, transport Transport) error {
+		party, err := newTestSigningParty(byId[id])
+		if err != nil {
+			return err
+		}
+		_, err = ConnectAndSignMessageWithTransportContext(ctx, party, transport, "signing", "hello world")
+		return err
+	}
+
+	firstSelected := make(chan struct{})
+	errs := make(map[string]error)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	run := func(id string, transport Transport) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := sign(id, transport)
+			lock.Lock()
+			errs[id] = err
+			lock.Unlock()
+		}()
+	}
+	run(first, &presenceFilter{Transport: hub.NewTransport(), minPeers: 2, forwarded: firstSelected})
+	run(third, &presenceFilter{Transport: hub.NewTransport(), minPeers: 3, forwarded: make(chan struct{})})
+	<-firstSelected
+	run(second, hub.NewTransport())
+	wg.Wait()
+
+	for id, err := range errs {
+		if err == nil {
+			t.Fatalf("party %s signed", id)
+		}
+		var timeout *TimeoutError
+		if errors.As(err, &timeout) || errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("party %s waited: %s", id, err)
+		}
+	}
+	if !strings.Contains(errs[first].Error(), "selected the signers") {
+		t.Fatalf("expected the first holder to name the quorums, got: %s", errs[first])
+	}
+	if !errors.Is(errs[third], ErrStandBy) {
+		t.Fatalf("expected the third holder to stand by, got: %s", errs[third])
+	}
+}
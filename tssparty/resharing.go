@@ -0,0 +1,67 @@
+package tssparty
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"slices"
+
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+func NewResharingTssPartyState(localParty *tss.PartyID, oldCommittee bool, n int, t int, newN int, newT int) *resharingTssPartyState {
+	state := NewTssPartyState(localParty, n, t)
+	state.oldCommittee = oldCommittee
+	state.ceremony.Protocol = ProtocolResharing
+	state.ceremony.NewN = newN
+	state.ceremony.NewT = newT
+	return &resharingTssPartyState{
+		tssPartyState: state,
+		newN:          newN,
+		newT:          newT,
+	}
+}
+
+func (party *resharingTssPartyState) withCurve(curve string) *resharingTssPartyState {
+	party.ceremony.Curve = curve
+	return party
+}
+
+func (party *resharingTssPartyState) GetNewPartyCount() int {
+	return party.newN
+}
+
+func (party *resharingTssPartyState) GetNewThreshold() int {
+	return party.newT
+}
+
+func (party *resharingTssPartyState) IsOldCommittee() bool {
+	return party.oldCommittee
+}
+
+func (party *resharingTssPartyState) GetResharingParams(ec elliptic.Curve) (*tss.ReSharingParameters, error) {
+	oldParties, newParties := party.oldCommitteeParties(), party.newCommitteeParties()
+
+	if len(oldParties) != party.t+1 {
+		return nil, fmt.Errorf("expected %v parties from the old committee but got %v", party.t+1, len(oldParties))
+	}
+	if len(newParties) != party.newN {
+		return nil, fmt.Errorf("expected %v parties from the new committee but got %v", party.newN, len(newParties))
+	}
+
+	// each committee is indexed on its own
+	oldCtx := tss.NewPeerContext(tss.SortPartyIDs(oldParties))
+	newCtx := tss.NewPeerContext(tss.SortPartyIDs(newParties))
+	return tss.NewReSharingParameters(ec, oldCtx, newCtx, party.thisParty, party.n, party.t, party.newN, party.newT), nil
+}
+
+func (party *resharingTssPartyState) oldCommitteeParties() []*tss.PartyID {
+	return slices.DeleteFunc(slices.Clone(party.sortedParties), func(p *tss.PartyID) bool {
+		return !party.oldCommitteeIds[p.Id]
+	})
+}
+
+func (party *resharingTssPartyState) newCommitteeParties() []*tss.PartyID {
+	return slices.DeleteFunc(slices.Clone(party.sortedParties), func(p *tss.PartyID) bool {
+		return party.oldCommitteeIds[p.Id]
+	})
+}
@@ -0,0 +1,73 @@
+package tssparty
+
+import (
+	"fmt"
+	"testing"
+)
+
+// testReshare hands the key of the old shares over to a new committee of newN parties named q0 to qnewN-1
+func testReshare(t *testing.T, hub *MemoryHub, curve string, old []string, n int, threshold int, newN int, newT int) []string {
+	t.Helper()
+	shares, errs := runParties(len(old)+newN, func(i int) (string, error) {
+		var party ResharingTssParty
+		var err error
+		switch {
+		case i < len(old) && curve == CurveEd25519:
+			party, err = NewEddsaResharingTssParty("", old[i], n, threshold, newN, newT)
+		case i < len(old):
+			party, err = NewEcdsaResharingTssParty("", old[i], n, threshold, newN, newT)
+		case curve == CurveEd25519:
+			party, err = NewEddsaResharingTssParty(fmt.Sprintf("q%d", i-len(old)), "", n, threshold, newN, newT)
+		default:
+			// the preparams of the keygen parties are not reused
+			party, err = NewEcdsaResharingTssPartyWithPreParams(fmt.Sprintf("q%d", i-len(old)), preParamsFor(t, n+i-len(old)), n, threshold, newN, newT)
+		}
+		if err != nil {
+			return "", err
+		}
+		party.SetInsecure(true)
+		return ConnectAndReshareKeyWithTransport(party, hub.NewTransport(), "resharing")
+	})
+	requireNoErrors(t, errs)
+	return shares[len(old):]
+}
+
+func TestResharing(t *testing.T) {
+	tests := []struct {
+		curve      string
+		n, t       int
+		newN, newT int
+	}{
+		{CurveEd25519, 3, 1, 4, 2},
+		{CurveSecp256k1, 3, 1, 3, 1},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %v-of-%v to %v-of-%v", test.curve, test.t+1, test.n, test.newT+1, test.newN), func(t *testing.T) {
+			hub := NewMemoryHub()
+			shares := testKeygen(t, hub, test.curve, test.n, test.t)
+			original, err := ParseKeyShare(shares[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			newShares := testReshare(t, hub, test.curve, shares[:test.t+1], test.n, test.t, test.newN, test.newT)
+			for _, keyShare := range newShares {
+				share, err := ParseKeyShare(keyShare)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if share.PublicKey != original.PublicKey || share.ChainCode != original.ChainCode {
+					t.Fatalf("the key changed from %s to %s", original.PublicKey, share.PublicKey)
+				}
+				if share.N != test.newN || share.T != test.newT {
+					t.Fatalf("reshared to %v-of-%v", share.T+1, share.N)
+				}
+			}
+
+			signatures := testSign(t, hub, newShares[:test.newT+1], "signing", "hello world")
+			for i, signature := range signatures {
+				requireValidSignature(t, newShares[i], "hello world", signature)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package tssparty
+
+import (
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/bnb-chain/tss-lib/v2/common"
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+type SignatureFormat string
+
+const (
+	SignatureFormatJson     SignatureFormat = "json"    // tss-lib signature data
+	SignatureFormatEthereum SignatureFormat = "eth"     // r || s || v
+	SignatureFormatDer      SignatureFormat = "der"     // ASN.1 DER with low-S, as used by bitcoin
+	SignatureFormatCompact  SignatureFormat = "compact" // r || s with low-S
+	SignatureFormatEd25519  SignatureFormat = "ed25519" // R || S as defined by RFC 8032
+)
+
+func ParseSignatureFormat(format string) (SignatureFormat, error) {
+	switch SignatureFormat(format) {
+	case SignatureFormatJson, SignatureFormatEthereum, SignatureFormatDer, SignatureFormatCompact, SignatureFormatEd25519:
+		return SignatureFormat(format), nil
+	}
+	return "", fmt.Errorf("unknown signature format %s", format)
+}
+
+// Signature is the output of a signing ceremony, the tss-lib signature data along with
+// the curve and the hash mode that turned the message into the signed value
+type Signature struct {
+	Curve    string   `json:"curve,omitempty"`
+	HashMode HashMode `json:"hashMode,omitempty"`
+	Path     string   `json:"path,omitempty"` // BIP32 path of the child key that signed, if any
+	*common.SignatureData
+}
+
+func newSignature(curve string, hashMode HashMode, path []uint32, data *common.SignatureData) (string, error) {
+	signature := Signature{
+		Curve:         curve,
+		HashMode:      hashMode,
+		SignatureData: data,
+	}
+	if len(path) > 0 {
+		signature.Path = formatDerivationPath(path)
+	}
+	jsonSignature, err := json.Marshal(signature)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonSignature), nil
+}
+
+// JsonToSignature reads the output of a signing ceremony, older outputs only hold the signature data
+func JsonToSignature(jsonSignature string) (*Signature, error) {
+	signature := Signature{SignatureData: &common.SignatureData{}}
+	err := json.Unmarshal([]byte(jsonSignature), &signature)
+	if err != nil {
+		return nil, err
+	}
+	return &signature, nil
+}
+
+// EncodeSignature encodes the output of a signing ceremony. With the ethereum format a non zero
+// chainId gives an EIP-155 recovery value, which takes more than one byte past chain id 109.
+func EncodeSignature(jsonSignature string, format SignatureFormat, chainId uint64) ([]byte, error) {
+	signature, err := JsonToSignature(jsonSignature)
+	if err != nil {
+		return nil, err
+	}
+	if format == SignatureFormatJson {
+		return json.Marshal(signature)
+	}
+
+	// only ecdsa signatures carry a recovery id
+	isEcdsa := len(signature.SignatureRecovery) == 1
+	if signature.Curve != "" {
+		isEcdsa = signature.Curve == CurveSecp256k1
+	}
+	if format == SignatureFormatEd25519 {
+		if isEcdsa || len(signature.Signature) != 64 {
+			return nil, fmt.Errorf("not an eddsa signature")
+		}
+		return signature.Signature, nil
+	}
+	if !isEcdsa {
+		return nil, fmt.Errorf("not an ecdsa signature")
+	}
+
+	r := new(big.Int).SetBytes(signature.R)
+	s := new(big.Int).SetBytes(signature.S)
+	n := tss.S256().Params().N
+	if r.Sign() == 0 || s.Sign() == 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("invalid ecdsa signature: r and s must be between 1 and the curve order")
+	}
+
+	// s and n - s are both valid, only the lower one is accepted by bitcoin and ethereum
+	flipped := s.Cmp(new(big.Int).Rsh(n, 1)) > 0
+	if flipped {
+		s.Sub(n, s)
+	}
+
+	switch format {
+	case SignatureFormatDer:
+		return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+
+	case SignatureFormatCompact:
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...), nil
+
+	case SignatureFormatEthereum:
+		if len(signature.SignatureRecovery) != 1 {
+			return nil, fmt.Errorf("the signature has no recovery id, it cannot be encoded for ethereum")
+		}
+		recovery := signature.SignatureRecovery[0]
+		if flipped {
+			recovery ^= 1
+		}
+		if recovery > 1 {
+			return nil, fmt.Errorf("recovery id %v cannot be encoded for ethereum", recovery)
+		}
+		v := big.NewInt(int64(recovery) + 27)
+		if chainId != 0 {
+			v.SetUint64(chainId)
+			v.Lsh(v, 1)
+			v.Add(v, big.NewInt(int64(recovery)+35))
+		}
+		encoded := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		return append(encoded, v.Bytes()...), nil
+	}
+	return nil, fmt.Errorf("unknown signature format %s", format)
+}
@@ -0,0 +1,72 @@
+package tssparty
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/bnb-chain/tss-lib/v2/common"
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+func testSignature(t *testing.T, curve string, r *big.Int, s *big.Int, recovery []byte) string {
+	t.Helper()
+	signature, err := newSignature(curve, DefaultHashMode(curve), nil, &common.SignatureData{
+		Signature:         append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...),
+		R:                 r.Bytes(),
+		S:                 s.Bytes(),
+		SignatureRecovery: recovery,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func TestEncodeSignature(t *testing.T) {
+	n := tss.S256().Params().N
+	one, two := big.NewInt(1), big.NewInt(2)
+	highS := new(big.Int).Sub(n, two)
+	rs := strings.Repeat("00", 31) + "01" + strings.Repeat("00", 31) + "02"
+
+	tests := []struct {
+		name      string
+		signature string
+		format    SignatureFormat
+		chainId   uint64
+		want      string // hex, empty when an error is expected
+	}{
+		{"compact", testSignature(t, CurveSecp256k1, one, two, []byte{1}), SignatureFormatCompact, 0, rs},
+		{"der", testSignature(t, CurveSecp256k1, one, two, []byte{1}), SignatureFormatDer, 0, "3006020101020102"},
+		{"eth", testSignature(t, CurveSecp256k1, one, two, []byte{1}), SignatureFormatEthereum, 0, rs + "1c"},
+		{"eth with a chain id", testSignature(t, CurveSecp256k1, one, two, []byte{0}), SignatureFormatEthereum, 1, rs + "25"},
+		{"eth with high s", testSignature(t, CurveSecp256k1, one, highS, []byte{1}), SignatureFormatEthereum, 0, rs + "1b"},
+		{"compact with high s", testSignature(t, CurveSecp256k1, one, highS, []byte{1}), SignatureFormatCompact, 0, rs},
+		{"compact without recovery id", testSignature(t, CurveSecp256k1, one, two, nil), SignatureFormatCompact, 0, rs},
+		{"der without recovery id", testSignature(t, CurveSecp256k1, one, two, nil), SignatureFormatDer, 0, "3006020101020102"},
+		{"eth without recovery id", testSignature(t, CurveSecp256k1, one, two, nil), SignatureFormatEthereum, 0, ""},
+		{"eth of an eddsa signature", testSignature(t, CurveEd25519, one, two, nil), SignatureFormatEthereum, 0, ""},
+		{"ed25519", testSignature(t, CurveEd25519, one, two, nil), SignatureFormatEd25519, 0, rs},
+		{"r above the curve order", testSignature(t, CurveSecp256k1, n, two, []byte{0}), SignatureFormatCompact, 0, ""},
+		{"zero s", testSignature(t, CurveSecp256k1, one, big.NewInt(0), []byte{0}), SignatureFormatDer, 0, ""},
+	}
+	for _, test := range tests {
+		encoded, err := EncodeSignature(test.signature, test.format, test.chainId)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		want, _ := hex.DecodeString(test.want)
+		if !bytes.Equal(encoded, want) {
+			t.Errorf("%s: got %x, want %x", test.name, encoded, want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package tssparty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+// Timeouts bounds each phase of a ceremony, a zero duration waits forever
+type Timeouts struct {
+	GuestWait  time.Duration // for all guests to join the session
+	IdExchange time.Duration // for all peers to announce themselves
+	Round      time.Duration // for a protocol round, restarted each time a tss message is exchanged
+	PreParams  time.Duration // for the ecdsa preparams of a party created without them, a minute when zero
+}
+
+// TimeoutError is returned when a step of the ceremony did not complete in time
+type TimeoutError struct {
+	Step    string
+	Missing []string
+}
+
+func (err *TimeoutError) Error() string {
+	if len(err.Missing) == 0 {
+		return fmt.Sprintf("timeout while %s", err.Step)
+	}
+	return fmt.Sprintf("timeout while %s, missing peers: [ %s ]", err.Step, strings.Join(err.Missing, ", "))
+}
+
+// leftPeerGrace is how long the id exchange waits for the announcement of a peer that left the session
+const leftPeerGrace = time.Second
+
+var roundRegexp = regexp.MustCompile(`Round(\d+)`)
+
+func (party *tssPartyState) SetTimeouts(timeouts Timeouts) {
+	party.timeouts = timeouts
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// contextError turns an expired deadline into a TimeoutError naming the step and the missing peers
+func contextError(ctx context.Context, step string, missing []string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Step: step, Missing: missing}
+	}
+	return ctx.Err()
+}
+
+// missingPeers lists the expected peers, other than the local one, that are not in seen
+func (party *tssPartyState) missingPeers(expected []string, seen map[string]bool) []string {
+	missing := []string{}
+	for _, id := range expected {
+		if id != party.thisParty.Id && !seen[id] {
+			missing = append(missing, id)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// expectedPeers is the signing quorum or the roster if any, or the guests that joined the session
+func (party *tssPartyState) expectedPeers() []string {
+	if quorum := party.quorum(); quorum != nil {
+		return quorum
+	}
+	if party.roster == nil {
+		return party.guests
+	}
+	expected := make([]string, 0, len(party.roster))
+	for id := range party.roster {
+		expected = append(expected, id)
+	}
+	return expected
+}
+
+// notifyProgress restarts the round timeout
+func (party *tssPartyState) notifyProgress() {
+	select {
+	case party.progress <- struct{}{}:
+	default:
+	}
+}
+
+// messageRound tells the round of a tss message from its type
+func messageRound(msg tss.Message) (int32, bool) {
+	match := roundRegexp.FindStringSubmatch(msg.Type())
+	if match == nil {
+		return 0, false
+	}
+	round, err := strconv.ParseInt(match[1], 10, 32)
+	if err != nil {
+		logger.Warnf("cannot tell the round of message %s: %s", msg.Type(), err.Error())
+		return 0, false
+	}
+	return int32(round), true
+}
+
+// trackRound records the round of the last message sent by the local party,
+// this is the round whose messages the local party is waiting for
+func (party *tssPartyState) trackRound(msg tss.Message) {
+	round, ok := messageRound(msg)
+	if ok && round > party.round.Load() {
+		party.round.Store(round)
+	}
+}
+
+// trackPeerRound records the round of the last message received from a peer, only the
+// goroutine feeding the tss parties with their messages calls it
+func (party *tssPartyState) trackPeerRound(from string, msg tss.Message) {
+	round, ok := messageRound(msg)
+	if !ok {
+		return
+	}
+	if last, found := party.received.Load(from); !found || round > last.(int32) {
+		party.received.Store(from, round)
+	}
+}
+
+func (party *tssPartyState) roundStep(task string) string {
+	round := party.round.Load()
+	if round == 0 {
+		round = 1
+	}
+	return fmt.Sprintf("waiting for %s round %d", task, round)
+}
+
+// waitForResult waits for the local tss party to output its result, a round that makes
+// no progress within the round timeout aborts the ceremony
+func waitForResult[T any](ctx context.Context, party *tssPartyState, task string, localParty tss.Party, endCh <-chan T) (T, error) {
+	var none T
+	results, err := waitForResults(ctx, party, task, []tss.Party{localParty}, []<-chan T{endCh})
+	if err != nil {
+		return none, err
+	}
+	return results[0], nil
+}
+
+// waitForResults waits for every tss party of a batch to output its result, in order
+func waitForResults[T any](ctx context.Context, party *tssPartyState, task string, localParties []tss.Party, endChs []<-chan T) ([]T, error) {
+	type result struct {
+		index int
+		value T
+	}
+	done := make(chan result, len(endChs))
+	for i, endCh := range endChs {
+		go func(i int, endCh <-chan T) {
+			select {
+			case value := <-endCh:
+				done <- result{i, value}
+			case <-party.stopped:
+			}
+		}(i, endCh)
+	}
+
+	var roundTimer <-chan time.Time
+	var timer *time.Timer
+	if party.timeouts.Round > 0 {
+		timer = time.NewTimer(party.timeouts.Round)
+		defer timer.Stop()
+		roundTimer = timer.C
+	}
+
+	results := make([]T, len(endChs))
+	finished := make([]bool, len(endChs))
+	pending := func() []tss.Party {
+		var parties []tss.Party
+		for i, localParty := range localParties {
+			if !finished[i] {
+				parties = append(parties, localParty)
+			}
+		}
+		return parties
+	}
+
+	for remaining := len(endChs); remaining > 0; {
+		select {
+		case ret := <-done:
+			results[ret.index] = ret.value
+			finished[ret.index] = true
+			remaining--
+		case err := <-party.failures:
+			if err.Task == "" {
+				err.Task = task
+				err.Round = int(party.round.Load())
+			}
+			return nil, err
+		case <-party.progress:
+			if timer != nil {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(party.timeouts.Round)
+			}
+		case <-roundTimer:
+			return nil, &TimeoutError{Step: party.roundStep(task), Missing: party.waitingFor(pending()...)}
+		case <-ctx.Done():
+			return nil, contextError(ctx, party.roundStep(task), party.waitingFor(pending()...))
+		}
+	}
+	return results, nil
+}
+
+// waitingFor lists the peers whose messages the local parties are waiting for. A tss-lib round
+// stops looking at the first message it misses and lists the peers after it too, so the peers
+// that already sent a message of the current round are left out when some did not.
+func (party *tssPartyState) waitingFor(localParties ...tss.Party) []string {
+	round := party.round.Load()
+	seen := make(map[string]bool)
+	missing := []string{}
+	var behind []string
+	for _, localParty := range localParties {
+		for _, p := range localParty.WaitingFor() {
+			if p.Id == party.thisParty.Id || seen[p.Id] {
+				continue
+			}
+			seen[p.Id] = true
+			missing = append(missing, p.Id)
+			if last, found := party.received.Load(p.Id); !found || last.(int32) < round {
+				behind = append(behind, p.Id)
+			}
+		}
+	}
+	if len(behind) > 0 {
+		missing = behind
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// stopProcessingMessages stops the pumps between the local tss party and the transport
+func (party *tssPartyState) stopProcessingMessages() {
+	party.stopOnce.Do(func() {
+		close(party.stopped)
+	})
+}
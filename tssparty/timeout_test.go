@@ -0,0 +1,82 @@
+package tssparty
+
+import (
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRoundTimeout(t *testing.T) {
+	hub := NewMemoryHub()
+	_, errs := runParties(3, func(i int) (string, error) {
+		party, err := newTestKeygenParty(t, CurveEd25519, i, 3, 1)
+		if err != nil {
+			return "", err
+		}
+		party.SetTimeouts(Timeouts{Round: 2 * time.Second})
+		transport := hub.NewTransport()
+		if i == 2 {
+			// p2 stalls once it sent its message of the first round
+			var sent atomic.Int32
+			transport = &tamperingTransport{transport, tamperTssMessages(func(to []string, msg *peerMessage) bool {
+				return sent.Add(1) == 1
+			})}
+		}
+		return ConnectAndGetKeyShareWithTransport(party, transport, "keygen")
+	})
+
+	for i, err := range errs[:2] {
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("party %v: expected a timeout, got: %v", i, err)
+		}
+		if timeoutErr.Step != "waiting for keygen round 2" || !slices.Equal(timeoutErr.Missing, []string{"p2"}) {
+			t.Fatalf("party %v: unexpected timeout: %s", i, timeoutErr)
+		}
+	}
+}
+
+func TestIdExchangeTimeout(t *testing.T) {
+	hub := NewMemoryHub()
+	// p2 joins the session but never announces itself
+	silent := hub.NewTransport()
+	err := silent.Connect("keygen", "p2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+
+	_, errs := runParties(2, func(i int) (string, error) {
+		party, err := newTestKeygenParty(t, CurveEd25519, i, 3, 1)
+		if err != nil {
+			return "", err
+		}
+		party.SetTimeouts(Timeouts{IdExchange: time.Second})
+		return ConnectAndGetKeyShareWithTransport(party, hub.NewTransport(), "keygen")
+	})
+	for i, err := range errs {
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("party %v: expected a timeout, got: %v", i, err)
+		}
+		if timeoutErr.Step != "exchanging ids" || !slices.Equal(timeoutErr.Missing, []string{"p2"}) {
+			t.Fatalf("party %v: unexpected timeout: %s", i, timeoutErr)
+		}
+	}
+}
+
+func TestGuestWaitTimeout(t *testing.T) {
+	hub := NewMemoryHub()
+	party, err := newTestKeygenParty(t, CurveEd25519, 0, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	party.SetTimeouts(Timeouts{GuestWait: 500 * time.Millisecond})
+	_, err = ConnectAndGetKeyShareWithTransport(party, hub.NewTransport(), "keygen")
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Step != "waiting for guests" {
+		t.Fatalf("expected a timeout while waiting for guests, got: %v", err)
+	}
+}
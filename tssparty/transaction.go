@@ -0,0 +1,247 @@
+package tssparty
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// EthereumTransaction is an unsigned ethereum transaction, as hashed with keccak256 to be signed
+type EthereumTransaction struct {
+	Type                 string   // legacy, EIP-2930 or EIP-1559
+	ChainId              *big.Int // nil for a legacy transaction without EIP-155 replay protection
+	Nonce                *big.Int
+	GasPrice             *big.Int // nil for an EIP-1559 transaction
+	MaxPriorityFeePerGas *big.Int // EIP-1559 only
+	MaxFeePerGas         *big.Int // EIP-1559 only
+	GasLimit             *big.Int
+	To                   string   // EIP-55 checksummed address, empty for a contract creation
+	Value                *big.Int // in wei
+	Data                 []byte
+	AccessList           int // number of entries of the access list
+}
+
+// rlpItem is a decoded RLP item, the content of a list is still encoded
+type rlpItem struct {
+	list    bool
+	content []byte
+}
+
+// ParseEthereumTransaction decodes the unsigned encoding of a legacy, EIP-2930 or EIP-1559 transaction,
+// the message signed by an ethereum wallet. Non canonical encodings and signed transactions are rejected.
+func ParseEthereumTransaction(raw []byte) (*EthereumTransaction, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("empty transaction")
+	}
+
+	tx := &EthereumTransaction{}
+	var items []rlpItem
+	var ints []**big.Int
+	var err error
+	switch {
+	case raw[0] == 0x01:
+		tx.Type = "EIP-2930"
+		items, err = rlpList(raw[1:], 8)
+		ints = []**big.Int{&tx.ChainId, &tx.Nonce, &tx.GasPrice, &tx.GasLimit}
+	case raw[0] == 0x02:
+		tx.Type = "EIP-1559"
+		items, err = rlpList(raw[1:], 9)
+		ints = []**big.Int{&tx.ChainId, &tx.Nonce, &tx.MaxPriorityFeePerGas, &tx.MaxFeePerGas, &tx.GasLimit}
+	case raw[0] >= 0xc0:
+		// with EIP-155 the chain id is followed by two zeros in place of the signature
+		tx.Type = "legacy"
+		items, err = rlpList(raw, 6, 9)
+		ints = []**big.Int{&tx.Nonce, &tx.GasPrice, &tx.GasLimit}
+	default:
+		return nil, fmt.Errorf("unknown transaction type %#x", raw[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range ints {
+		*value, err = rlpInt(items[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	items = items[len(ints):]
+	to, err := rlpString(items[0])
+	if err != nil {
+		return nil, err
+	}
+	switch len(to) {
+	case 0:
+	case 20:
+		tx.To = checksumAddress(to)
+	default:
+		return nil, fmt.Errorf("invalid recipient address of %v bytes", len(to))
+	}
+	tx.Value, err = rlpInt(items[1])
+	if err != nil {
+		return nil, err
+	}
+	tx.Data, err = rlpString(items[2])
+	if err != nil {
+		return nil, err
+	}
+
+	items = items[3:]
+	switch {
+	case tx.Type != "legacy":
+		tx.AccessList, err = rlpAccessList(items[0])
+	case len(items) == 3:
+		tx.ChainId, err = rlpInt(items[0])
+		if err == nil && (len(items[1].content) > 0 || len(items[2].content) > 0) {
+			err = fmt.Errorf("the transaction is already signed")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// rlpAccessList checks the entries of an access list, an address and a list of storage keys each, and counts them
+func rlpAccessList(item rlpItem) (int, error) {
+	if !item.list {
+		return 0, fmt.Errorf("the access list is not a list")
+	}
+	entries, err := rlpItems(item.content)
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		fields, err := rlpItems(entry.content)
+		if err != nil {
+			return 0, err
+		}
+		if !entry.list || len(fields) != 2 || fields[0].list || len(fields[0].content) != 20 || !fields[1].list {
+			return 0, fmt.Errorf("invalid access list entry")
+		}
+		keys, err := rlpItems(fields[1].content)
+		if err != nil {
+			return 0, err
+		}
+		for _, key := range keys {
+			if key.list || len(key.content) != 32 {
+				return 0, fmt.Errorf("invalid access list storage key")
+			}
+		}
+	}
+	return len(entries), nil
+}
+
+// rlpList decodes raw as a single list with one of the given numbers of items
+func rlpList(raw []byte, counts ...int) ([]rlpItem, error) {
+	item, rest, err := rlpSplit(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !item.list || len(rest) > 0 {
+		return nil, fmt.Errorf("the transaction is not a single RLP list")
+	}
+	items, err := rlpItems(item.content)
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range counts {
+		if len(items) == count {
+			return items, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected transaction of %v fields", len(items))
+}
+
+// rlpItems decodes the content of a list
+func rlpItems(content []byte) ([]rlpItem, error) {
+	var items []rlpItem
+	for len(content) > 0 {
+		item, rest, err := rlpSplit(content)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		content = rest
+	}
+	return items, nil
+}
+
+// rlpSplit decodes the first item of raw and returns the bytes that follow it
+func rlpSplit(raw []byte) (rlpItem, []byte, error) {
+	if len(raw) == 0 {
+		return rlpItem{}, nil, fmt.Errorf("truncated RLP item")
+	}
+	prefix := raw[0]
+	var item rlpItem
+	var offset, size int
+	var err error
+	switch {
+	case prefix < 0x80:
+		return rlpItem{content: raw[:1]}, raw[1:], nil
+	case prefix < 0xb8:
+		offset, size = 1, int(prefix-0x80)
+	case prefix < 0xc0:
+		offset, size, err = rlpLongSize(raw, int(prefix-0xb7))
+	case prefix < 0xf8:
+		item.list = true
+		offset, size = 1, int(prefix-0xc0)
+	default:
+		item.list = true
+		offset, size, err = rlpLongSize(raw, int(prefix-0xf7))
+	}
+	if err != nil {
+		return rlpItem{}, nil, err
+	}
+	if size > len(raw)-offset {
+		return rlpItem{}, nil, fmt.Errorf("truncated RLP item")
+	}
+	item.content = raw[offset : offset+size]
+	if !item.list && size == 1 && item.content[0] < 0x80 {
+		return rlpItem{}, nil, fmt.Errorf("non canonical RLP encoding of a single byte")
+	}
+	return item, raw[offset+size:], nil
+}
+
+// rlpLongSize decodes the size of an item of 56 bytes or more, encoded on sizeLength bytes after the prefix
+func rlpLongSize(raw []byte, sizeLength int) (int, int, error) {
+	if len(raw) < 1+sizeLength {
+		return 0, 0, fmt.Errorf("truncated RLP item")
+	}
+	if raw[1] == 0 {
+		return 0, 0, fmt.Errorf("non canonical RLP size")
+	}
+	size := 0
+	for _, b := range raw[1 : 1+sizeLength] {
+		if size > len(raw)>>8 {
+			return 0, 0, fmt.Errorf("truncated RLP item")
+		}
+		size = size<<8 | int(b)
+	}
+	if size < 56 {
+		return 0, 0, fmt.Errorf("non canonical RLP size")
+	}
+	return 1 + sizeLength, size, nil
+}
+
+// rlpString returns the bytes of a string item
+func rlpString(item rlpItem) ([]byte, error) {
+	if item.list {
+		return nil, fmt.Errorf("unexpected RLP list in place of a string")
+	}
+	return item.content, nil
+}
+
+// rlpInt decodes a string item as a big endian unsigned integer of at most 256 bits
+func rlpInt(item rlpItem) (*big.Int, error) {
+	content, err := rlpString(item)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > 32 {
+		return nil, fmt.Errorf("integer of %v bytes is too large", len(content))
+	}
+	if len(content) > 0 && content[0] == 0 {
+		return nil, fmt.Errorf("non canonical RLP integer with leading zeros")
+	}
+	return new(big.Int).SetBytes(content), nil
+}
@@ -0,0 +1,78 @@
+package tssparty
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestParseEthereumTransaction(t *testing.T) {
+	gwei := func(amount int64) *big.Int { return new(big.Int).Mul(big.NewInt(amount), big.NewInt(1e9)) }
+	tests := []struct {
+		name string
+		raw  string
+		want EthereumTransaction
+	}{
+		// the example of EIP-155
+		{"legacy", "ec098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080018080", EthereumTransaction{
+			Type: "legacy", ChainId: big.NewInt(1), Nonce: big.NewInt(9), GasPrice: gwei(20), GasLimit: big.NewInt(21000),
+			To: "0x3535353535353535353535353535353535353535", Value: big.NewInt(1e18),
+		}},
+		{"legacy contract creation without chain id", "d280843b9aca00830186a08080856080604052", EthereumTransaction{
+			Type: "legacy", Nonce: big.NewInt(0), GasPrice: gwei(1), GasLimit: big.NewInt(100000),
+			Value: big.NewInt(0), Data: []byte{0x60, 0x80, 0x60, 0x40, 0x52},
+		}},
+		{"EIP-1559", "02f0018084773594008506fc23ac00825208945aaeb6053f3e94c9b9a09f33669435e7ef1beaed8806f05b59d3b2000080c0", EthereumTransaction{
+			Type: "EIP-1559", ChainId: big.NewInt(1), Nonce: big.NewInt(0), MaxPriorityFeePerGas: gwei(2), MaxFeePerGas: gwei(30),
+			GasLimit: big.NewInt(21000), To: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", Value: big.NewInt(5e17),
+		}},
+		{"EIP-2930 with an access list", "01f8c405078504a817c80082ea60945aaeb6053f3e94c9b9a09f33669435e7ef1beaed80b844a9059cbb0000000000000000000000005aaeb6053f3e94c9b9a09f33669435e7ef1beaed00000000000000000000000000000000000000000000000000000000000003e8f85bf859945aaeb6053f3e94c9b9a09f33669435e7ef1beaedf842a00000000000000000000000000000000000000000000000000000000000000000a00101010101010101010101010101010101010101010101010101010101010101", EthereumTransaction{
+			Type: "EIP-2930", ChainId: big.NewInt(5), Nonce: big.NewInt(7), GasPrice: gwei(20), GasLimit: big.NewInt(60000),
+			To: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", Value: big.NewInt(0), AccessList: 1,
+		}},
+	}
+	for _, test := range tests {
+		raw, _ := hex.DecodeString(test.raw)
+		tx, err := ParseEthereumTransaction(raw)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if tx.Type == "EIP-2930" {
+			// an ERC-20 transfer of 1000 units to the recipient
+			if len(tx.Data) != 68 || hex.EncodeToString(tx.Data[:4]) != "a9059cbb" {
+				t.Errorf("%s: unexpected data %x", test.name, tx.Data)
+			}
+			tx.Data = nil
+		}
+		if got, want := fmt.Sprintf("%+v", *tx), fmt.Sprintf("%+v", test.want); got != want {
+			t.Errorf("%s: got %s, want %s", test.name, got, want)
+		}
+	}
+}
+
+func TestParseEthereumTransactionRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"empty", ""},
+		{"text", hex.EncodeToString([]byte("hello world"))},
+		{"signed legacy transaction", "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"},
+		{"truncated", "ec098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a7640000800180"},
+		{"trailing bytes", "ec098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008001808000"},
+		{"integer with leading zeros", "ed09860004a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080018080"},
+		{"single byte as a string", "ed81098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080018080"},
+		{"short recipient", "eb098504a817c8008252089335353535353535353535353535353535353535880de0b6b3a764000080018080"},
+		{"EIP-1559 with missing fields", "02ee018084773594008506fc23ac00825208945aaeb6053f3e94c9b9a09f33669435e7ef1beaed8806f05b59d3b2000080"},
+		{"unknown type", "03c0"},
+	}
+	for _, test := range tests {
+		raw, _ := hex.DecodeString(test.raw)
+		_, err := ParseEthereumTransaction(raw)
+		if err == nil {
+			t.Errorf("%s: expected the transaction to be rejected", test.name)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package tssparty
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/swarmlab-dev/go-partybus/partybus"
+)
+
+// Transport carries the messages of a party to its peers during a ceremony.
+// Implementations must deliver the messages of a given sender in order.
+type Transport interface {
+	Connect(sessionId string, localId string) error
+	Unicast(to string, msg []byte) error
+	Multicast(to []string, msg []byte) error
+	Broadcast(msg []byte) error
+	Receive() <-chan TransportMessage
+	Presence() <-chan PresenceStatus
+	Close() error
+}
+
+// TransportMessage is a message received from the peer From
+type TransportMessage struct {
+	From string
+	Msg  []byte
+}
+
+// PresenceStatus lists the peers connected to the session, a Transport sends one whenever they change
+type PresenceStatus struct {
+	Peers []string
+}
+
+// PartyBusTransport is the default Transport, it relays messages through a go-partybus server
+type PartyBusTransport struct {
+	partyBusUrl string
+	localId     string
+	out         chan partybus.PeerMessage
+	in          chan TransportMessage
+	sig         chan PresenceStatus
+	closed      chan struct{} // closed once the connection to the bus is lost
+}
+
+func NewPartyBusTransport(partyBusUrl string) Transport {
+	return &PartyBusTransport{
+		partyBusUrl: partyBusUrl,
+	}
+}
+
+func (transport *PartyBusTransport) Connect(sessionId string, localId string) error {
+	if transport.out != nil {
+		return fmt.Errorf("transport is already connected")
+	}
+	out := make(chan partybus.PeerMessage)
+	busIn, sig, err := partybus.ConnectToPartyBus(transport.partyBusUrl, sessionId, localId, out)
+	if err != nil {
+		return err
+	}
+	transport.localId = localId
+	transport.out = out
+	transport.in = make(chan TransportMessage)
+	transport.sig = make(chan PresenceStatus)
+	transport.closed = make(chan struct{})
+
+	// the bus stops writing when it stops reading, the end of the incoming messages tells that it is gone
+	go func() {
+		defer close(transport.closed)
+		defer close(transport.in)
+		for msg := range busIn {
+			transport.in <- TransportMessage{From: msg.From, Msg: msg.Msg}
+		}
+	}()
+	go func() {
+		defer close(transport.sig)
+		for status := range sig {
+			transport.sig <- PresenceStatus{Peers: status.Peers}
+		}
+	}()
+	return nil
+}
+
+func (transport *PartyBusTransport) Unicast(to string, msg []byte) error {
+	return transport.Multicast([]string{to}, msg)
+}
+
+func (transport *PartyBusTransport) Multicast(to []string, msg []byte) error {
+	if len(to) == 0 {
+		return fmt.Errorf("multicast message needs at least one recipient")
+	}
+	return transport.send(partybus.NewMulticastMessage(transport.localId, to, msg))
+}
+
+func (transport *PartyBusTransport) Broadcast(msg []byte) error {
+	return transport.send(partybus.NewBroadcastMessage(transport.localId, msg))
+}
+
+func (transport *PartyBusTransport) Receive() <-chan TransportMessage {
+	return transport.in
+}
+
+func (transport *PartyBusTransport) Presence() <-chan PresenceStatus {
+	return transport.sig
+}
+
+// Close leaves the session. As the outgoing channel is unbuffered, handing over
+// the leave message also guarantees that every previous message was written.
+func (transport *PartyBusTransport) Close() error {
+	if transport.out == nil {
+		return nil
+	}
+	leave := partybus.PeerMessage{Type: partybus.LEAVE, From: transport.localId}
+	select {
+	case transport.out <- leave:
+	case <-transport.closed:
+	case <-time.After(time.Second):
+		return fmt.Errorf("timeout while leaving the party bus")
+	}
+	return nil
+}
+
+func (transport *PartyBusTransport) send(msg partybus.PeerMessage) error {
+	if transport.out == nil {
+		return fmt.Errorf("transport is not connected")
+	}
+	select {
+	case transport.out <- msg:
+		return nil
+	case <-transport.closed:
+		return fmt.Errorf("connection to the party bus is lost")
+	}
+}
@@ -1,37 +1,71 @@
 package tssparty
 
 import (
+	"context"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
 	"fmt"
+	"sync"
+	"sync/atomic"
 
 	ecdsaKeygen "github.com/bnb-chain/tss-lib/v2/ecdsa/keygen"
 	eddsaKeygen "github.com/bnb-chain/tss-lib/v2/eddsa/keygen"
 	"github.com/bnb-chain/tss-lib/v2/tss"
-	"github.com/swarmlab-dev/go-partybus/partybus"
 )
 
 type TssParty interface {
 	GetPartyCount() int
 	GetThreshold() int
 
-	Init() error                                                  // step 1
-	ConnectToPartyBus(partyBusUrl string, sessionId string) error // step 2
-	WaitForGuests(n int) error                                    // step 3
-	ExchangeIds(n int) (string, error)                            // step 4
+	Init() error                                                    // step 1
+	ConnectToPartyBus(partyBusUrl string, sessionId string) error   // step 2
+	ConnectToTransport(transport Transport, sessionId string) error // step 2
+	WaitForGuests(n int) error                                      // step 3
+	ExchangeIds(n int) (string, error)                              // step 4
 
-	PrepareTransport(partyBusUrl string, sessionId string, n int) (string, error) // step 2, 3, 4
-	WaitForGuestsAndExchangeIDs(n int) (string, error)                            // step 3, 4
+	InitContext(ctx context.Context) error                         // step 1
+	WaitForGuestsContext(ctx context.Context, n int) error         // step 3
+	ExchangeIdsContext(ctx context.Context, n int) (string, error) // step 4
+
+	SetIdentity(identityKey ed25519.PrivateKey) error
+	SetRoster(roster []RosterEntry) error
+	SetInsecure(insecure bool)
+	SetTimeouts(timeouts Timeouts)
+
+	PrepareTransport(transport Transport, sessionId string, n int) (string, error)                             // step 2, 3, 4
+	PrepareTransportContext(ctx context.Context, transport Transport, sessionId string, n int) (string, error) // step 2, 3, 4
+	WaitForGuestsAndExchangeIDs(n int) (string, error)                                                         // step 3, 4
+	WaitForGuestsAndExchangeIDsContext(ctx context.Context, n int) (string, error)                             // step 3, 4
 	DisconnectFromBus() error
 	Clean() error
 }
 
 type KeygenTssParty interface {
 	TssParty
-	GetKeyShare() (string, error) // step 5
+	GetKeyShare() (string, error)                           // step 5
+	GetKeyShareContext(ctx context.Context) (string, error) // step 5
 }
 
 type SigningTssParty interface {
 	TssParty
-	SignMessage(msg string) (string, error) // step 5
+	SetHashMode(mode HashMode) error
+	SetSignerPreference(ids []string) error
+	SetDerivationPath(path string) error
+	SetApproval(approve Approval) error
+	SignMessage(msg string) (string, error)                                   // step 5
+	SignMessageContext(ctx context.Context, msg string) (string, error)       // step 5
+	SignMessages(msgs []string) ([]string, error)                             // step 5
+	SignMessagesContext(ctx context.Context, msgs []string) ([]string, error) // step 5
+}
+
+type ResharingTssParty interface {
+	TssParty
+	GetNewPartyCount() int
+	GetNewThreshold() int
+	IsOldCommittee() bool
+	ReshareKey() (string, error)                           // step 5
+	ReshareKeyContext(ctx context.Context) (string, error) // step 5
 }
 
 type tssPartyStep int64
@@ -54,13 +88,82 @@ type tssPartyState struct {
 	n         int
 	t         int
 
-	// transport partybus channel
+	// transport
 	aboardBus     bool
-	outBus        chan partybus.PeerMessage
-	inBus         chan partybus.PeerMessage
-	sigBus        chan partybus.StatusMessage
+	transport     Transport
+	sessionId     string
+	guests        []string
+	presence      chan []string // latest peers of the session, once the guests arrived
+	inbox         map[peerMessageType]*queue[inboundMessage]
+	outgoing      sync.WaitGroup
 	sortedParties []*tss.PartyID
 	partyIDMap    map[string]*tss.PartyID
+
+	// ceremony parameters announced to the peers
+	ceremony ceremonyParams
+
+	// ceremony progress
+	timeouts Timeouts
+	progress chan struct{}
+	round    atomic.Int32
+	received sync.Map // latest round received from each peer, as an int32 by id
+	stopped  chan struct{}
+	failures chan *ProtocolError
+	stopOnce sync.Once
+
+	// identities
+	identityKey ed25519.PrivateKey
+	roster      map[string]ed25519.PublicKey
+	insecure    bool // runs without a roster
+
+	// point-to-point encryption
+	encryptionKey *ecdh.PrivateKey
+	peerCiphers   map[string]*p2pCipher
+
+	// signing quorum
+	signers *signerSet
+
+	// resharing committees
+	oldCommittee    bool
+	oldCommitteeIds map[string]bool
+}
+
+// partyAnnouncement is broadcast by every party during the id exchange
+type partyAnnouncement struct {
+	PartyID       *tss.PartyID    `json:"partyId"`
+	OldCommittee  bool            `json:"oldCommittee,omitempty"`
+	EncryptionKey []byte          `json:"encryptionKey"`
+	Ceremony      *ceremonyParams `json:"ceremony"`
+	Signers       []string        `json:"signers,omitempty"`
+	Started       bool            `json:"started,omitempty"`
+}
+
+type peerMessageType int
+
+const (
+	ANNOUNCEMENT_MESSAGE peerMessageType = 1
+	TSS_MESSAGE          peerMessageType = 2
+	CONFIRMATION_MESSAGE peerMessageType = 3
+	INTENT_MESSAGE       peerMessageType = 4
+	COMMITMENT_MESSAGE   peerMessageType = 5
+	CHAIN_CODE_MESSAGE   peerMessageType = 6
+	PROPOSAL_MESSAGE     peerMessageType = 7
+)
+
+// peerMessage is the envelope of everything a party sends to its peers. It keeps the
+// broadcast flag of tss messages on transports that only know about recipients.
+type peerMessage struct {
+	Type        peerMessageType `json:"type"`
+	Instance    int             `json:"instance,omitempty"` // tss party of a batch
+	IsBroadcast bool            `json:"isBroadcast,omitempty"`
+	Encrypted   bool            `json:"encrypted,omitempty"`
+	Payload     []byte          `json:"payload"`
+}
+
+// inboundMessage is a peerMessage along with its sender on the transport
+type inboundMessage struct {
+	From string
+	peerMessage
 }
 
 type EcdsaKeygenTssPartyState struct {
@@ -75,11 +178,39 @@ type EddsaKeygenTssPartyState struct {
 type EcdsaSigningTssPartyState struct {
 	*tssPartyState
 	keyShare *ecdsaKeygen.LocalPartySaveData
+	envelope *KeyShare
+	hashMode HashMode
+	path     []uint32 // BIP32 derivation path of the child key to sign with
 }
 
 type EddsaSigningTssPartyState struct {
 	*tssPartyState
 	keyShare *eddsaKeygen.LocalPartySaveData
+	envelope *KeyShare
+	hashMode HashMode
+}
+
+type p2pCipher struct {
+	aead cipher.AEAD
+}
+
+type resharingTssPartyState struct {
+	*tssPartyState
+	newN int
+	newT int
+}
+
+type EcdsaResharingTssPartyState struct {
+	*resharingTssPartyState
+	keyShare  *ecdsaKeygen.LocalPartySaveData
+	envelope  *KeyShare
+	preParams *ecdsaKeygen.LocalPreParams
+}
+
+type EddsaResharingTssPartyState struct {
+	*resharingTssPartyState
+	keyShare *eddsaKeygen.LocalPartySaveData
+	envelope *KeyShare
 }
 
 // helper functions
@@ -90,6 +221,7 @@ func (party *tssPartyState) stateFunc(from tssPartyStep, to tssPartyStep, fun fu
 	}
 
 	if err := fun(); err != nil {
+		party.setState(ERROR)
 		return err
 	}
 
@@ -104,6 +236,7 @@ func (party *tssPartyState) stateFunc2(from tssPartyStep, to tssPartyStep, fun f
 
 	str, err := fun()
 	if err != nil {
+		party.setState(ERROR)
 		return "", err
 	}
 
@@ -27,3 +27,11 @@ func NewPartyIDFromKey(localID string, localKey string, base int) (*tss.PartyID,
 	}
 	return tss.NewPartyID(localID, localID, key), nil
 }
+
+func toSet(vs []string) map[string]bool {
+	set := make(map[string]bool, len(vs))
+	for _, v := range vs {
+		set[v] = true
+	}
+	return set
+}
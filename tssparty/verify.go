@@ -0,0 +1,113 @@
+package tssparty
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// VerifySignature checks a signature of message against a public key. For secp256k1 the public key
+// is SEC1 encoded and the signature is either r || s, r || s || v or DER encoded. For ed25519 both
+// are encoded as defined by RFC 8032.
+func VerifySignature(publicKey []byte, message []byte, signature []byte, curve string, hashMode HashMode) error {
+	digest, err := HashMessage(message, hashMode, curve)
+	if err != nil {
+		return err
+	}
+
+	switch curve {
+	case CurveEd25519:
+		if len(publicKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("expected a %v bytes ed25519 public key but got %v bytes", ed25519.PublicKeySize, len(publicKey))
+		}
+		if len(signature) != ed25519.SignatureSize {
+			return fmt.Errorf("expected a %v bytes ed25519 signature but got %v bytes", ed25519.SignatureSize, len(signature))
+		}
+		if !ed25519.Verify(publicKey, digest, signature) {
+			return fmt.Errorf("%w: does not match the public key and the message", ErrInvalidSignature)
+		}
+		return nil
+
+	case CurveSecp256k1:
+		pub, err := parseSecp256k1PublicKey(publicKey)
+		if err != nil {
+			return err
+		}
+		r, s, err := parseEcdsaSignature(signature)
+		if err != nil {
+			return err
+		}
+		n := tss.S256().Params().N
+		if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+			return fmt.Errorf("%w: r or s is out of range", ErrInvalidSignature)
+		}
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return fmt.Errorf("%w: does not match the public key and the message", ErrInvalidSignature)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported curve %s", curve)
+}
+
+// parseEcdsaSignature reads r || s, r || s || v or a DER encoded signature
+func parseEcdsaSignature(signature []byte) (*big.Int, *big.Int, error) {
+	if len(signature) == 64 || len(signature) == 65 {
+		return new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:64]), nil
+	}
+
+	var der struct{ R, S *big.Int }
+	rest, err := asn1.Unmarshal(signature, &der)
+	if err != nil || len(rest) != 0 {
+		return nil, nil, fmt.Errorf("%w: expected r || s, r || s || v or a DER signature but got %v bytes", ErrInvalidSignature, len(signature))
+	}
+	if !bytes.Equal(mustMarshalDer(der.R, der.S), signature) {
+		return nil, nil, fmt.Errorf("%w: DER encoding is not canonical", ErrInvalidSignature)
+	}
+	return der.R, der.S, nil
+}
+
+func mustMarshalDer(r, s *big.Int) []byte {
+	der, _ := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	return der
+}
+
+// parseSecp256k1PublicKey reads a compressed or uncompressed SEC1 public key
+func parseSecp256k1PublicKey(publicKey []byte) (*ecdsa.PublicKey, error) {
+	curve := tss.S256()
+	p := curve.Params().P
+	var x, y *big.Int
+
+	switch {
+	case len(publicKey) == 33 && (publicKey[0] == 2 || publicKey[0] == 3):
+		x = new(big.Int).SetBytes(publicKey[1:])
+		if x.Cmp(p) >= 0 {
+			return nil, fmt.Errorf("invalid secp256k1 public key")
+		}
+		// y² = x³ + 7, and p = 3 mod 4 so that y = (x³ + 7)^((p + 1) / 4)
+		y2 := new(big.Int).Exp(x, big.NewInt(3), p)
+		y2.Add(y2, curve.Params().B)
+		y2.Mod(y2, p)
+		y = new(big.Int).Exp(y2, new(big.Int).Rsh(new(big.Int).Add(p, big.NewInt(1)), 2), p)
+		if y.Bit(0) != uint(publicKey[0]&1) {
+			y.Sub(p, y)
+		}
+	case len(publicKey) == 65 && publicKey[0] == 4:
+		x = new(big.Int).SetBytes(publicKey[1:33])
+		y = new(big.Int).SetBytes(publicKey[33:])
+	default:
+		return nil, fmt.Errorf("expected a 33 or 65 bytes SEC1 public key but got %v bytes", len(publicKey))
+	}
+
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("public key is not on secp256k1")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
@@ -0,0 +1,96 @@
+package tssparty
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/bnb-chain/tss-lib/v2/tss"
+)
+
+func TestVerifyEd25519Signature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("hello world")
+	signature := ed25519.Sign(privateKey, message)
+
+	err = VerifySignature(publicKey, message, signature, CurveEd25519, HashModeEddsa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = VerifySignature(publicKey, []byte("hello world!"), signature, CurveEd25519, HashModeEddsa)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for another message, got: %v", err)
+	}
+	err = VerifySignature(publicKey, message, signature[:63], CurveEd25519, HashModeEddsa)
+	if err == nil {
+		t.Fatal("accepted a truncated signature")
+	}
+}
+
+func TestVerifySecp256k1Signature(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(tss.S256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("hello world")
+	digest, err := HashMessage(message, HashModeKeccak256, CurveSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x, y := privateKey.X.FillBytes(make([]byte, 32)), privateKey.Y.FillBytes(make([]byte, 32))
+	uncompressed := append(append([]byte{4}, x...), y...)
+	compressed := append([]byte{2 + byte(privateKey.Y.Bit(0))}, x...)
+	compact := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	der := mustMarshalDer(r, s)
+
+	valid := []struct {
+		name      string
+		publicKey []byte
+		signature []byte
+	}{
+		{"compact with a compressed key", compressed, compact},
+		{"compact with an uncompressed key", uncompressed, compact},
+		{"eth", compressed, append(compact, 27)},
+		{"der", compressed, der},
+	}
+	for _, test := range valid {
+		err := VerifySignature(test.publicKey, message, test.signature, CurveSecp256k1, HashModeKeccak256)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+	}
+
+	otherKey := append([]byte{5 - compressed[0]}, x...)
+	nonCanonicalDer := append([]byte{der[0], der[1] + 1, der[2], der[3] + 1, 0}, der[4:]...)
+	invalid := []struct {
+		name      string
+		publicKey []byte
+		message   string
+		hashMode  HashMode
+		signature []byte
+	}{
+		{"another message", compressed, "hello world!", HashModeKeccak256, compact},
+		{"another hash mode", compressed, "hello world", HashModeSha256, compact},
+		{"another key", otherKey, "hello world", HashModeKeccak256, compact},
+		{"zero r", compressed, "hello world", HashModeKeccak256, append(make([]byte, 32), compact[32:]...)},
+		{"non canonical der", compressed, "hello world", HashModeKeccak256, nonCanonicalDer},
+		{"truncated signature", compressed, "hello world", HashModeKeccak256, compact[:63]},
+		{"truncated key", compressed[:32], "hello world", HashModeKeccak256, compact},
+	}
+	for _, test := range invalid {
+		err := VerifySignature(test.publicKey, []byte(test.message), test.signature, CurveSecp256k1, test.hashMode)
+		if err == nil {
+			t.Errorf("%s: the signature was accepted", test.name)
+		}
+	}
+}